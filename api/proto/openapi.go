@@ -0,0 +1,9 @@
+package proto
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI v2 document generated from the google.api.http
+// annotations in user.proto.
+//
+//go:embed user.swagger.json
+var OpenAPI []byte
@@ -1,12 +1,14 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
 // 	protoc-gen-go v1.36.11
-// 	protoc        v6.33.2
+// 	protoc        (unknown)
 // source: user.proto
 
 package proto
 
 import (
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -1476,7 +1478,7 @@ var File_user_proto protoreflect.FileDescriptor
 const file_user_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"user.proto\x12\x05proto\"\x1a\n" +
+	"user.proto\x12\x05proto\x1a\x1bbuf/validate/validate.proto\x1a\x1cgoogle/api/annotations.proto\"\x1a\n" +
 	"\x04User\x12\x12\n" +
 	"\x04uuid\x18\x01 \x01(\tR\x04uuid\"\xab\x01\n" +
 	"\vUserDetails\x12\x12\n" +
@@ -1496,99 +1498,99 @@ const file_user_proto_rawDesc = "" +
 	"telegramId\x88\x01\x01\x12\x1b\n" +
 	"\tuser_uuid\x18\x04 \x01(\tR\buserUuidB\b\n" +
 	"\x06_emailB\x0e\n" +
-	"\f_telegram_id\"'\n" +
-	"\x11CreateUserRequest\x12\x12\n" +
-	"\x04uuid\x18\x01 \x01(\tR\x04uuid\"5\n" +
+	"\f_telegram_id\"1\n" +
+	"\x11CreateUserRequest\x12\x1c\n" +
+	"\x04uuid\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x04uuid\"5\n" +
 	"\x12CreateUserResponse\x12\x1f\n" +
-	"\x04user\x18\x01 \x01(\v2\v.proto.UserR\x04user\"4\n" +
-	"\x15GetUserDetailsRequest\x12\x1b\n" +
-	"\tuser_uuid\x18\x01 \x01(\tR\buserUuid\"F\n" +
+	"\x04user\x18\x01 \x01(\v2\v.proto.UserR\x04user\">\n" +
+	"\x15GetUserDetailsRequest\x12%\n" +
+	"\tuser_uuid\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\buserUuid\"F\n" +
 	"\x16GetUserDetailsResponse\x12,\n" +
-	"\adetails\x18\x01 \x01(\v2\x12.proto.UserDetailsR\adetails\"5\n" +
-	"\x16GetUserContactsRequest\x12\x1b\n" +
-	"\tuser_uuid\x18\x01 \x01(\tR\buserUuid\"J\n" +
+	"\adetails\x18\x01 \x01(\v2\x12.proto.UserDetailsR\adetails\"?\n" +
+	"\x16GetUserContactsRequest\x12%\n" +
+	"\tuser_uuid\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\buserUuid\"J\n" +
 	"\x17GetUserContactsResponse\x12/\n" +
-	"\bcontacts\x18\x01 \x01(\v2\x13.proto.UserContactsR\bcontacts\"'\n" +
-	"\x11DeleteUserRequest\x12\x12\n" +
-	"\x04uuid\x18\x01 \x01(\tR\x04uuid\"\x14\n" +
-	"\x12DeleteUserResponse\"\xb8\x01\n" +
-	"\x18CreateUserDetailsRequest\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
-	"\asurname\x18\x02 \x01(\tR\asurname\x12#\n" +
+	"\bcontacts\x18\x01 \x01(\v2\x13.proto.UserContactsR\bcontacts\"1\n" +
+	"\x11DeleteUserRequest\x12\x1c\n" +
+	"\x04uuid\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\x04uuid\"\x14\n" +
+	"\x12DeleteUserResponse\"\xbd\x02\n" +
+	"\x18CreateUserDetailsRequest\x12,\n" +
+	"\x04name\x18\x01 \x01(\tB\x18\xbaH\x15r\x132\x11^[\\p{L}\\_\\-\\. ]+$R\x04name\x122\n" +
+	"\asurname\x18\x02 \x01(\tB\x18\xbaH\x15r\x132\x11^[\\p{L}\\_\\-\\. ]+$R\asurname\x12=\n" +
 	"\n" +
-	"patronymic\x18\x03 \x01(\tH\x00R\n" +
-	"patronymic\x88\x01\x01\x12\x1d\n" +
+	"patronymic\x18\x03 \x01(\tB\x18\xbaH\x15r\x132\x11^[\\p{L}\\_\\-\\. ]+$H\x00R\n" +
+	"patronymic\x88\x01\x01\x12J\n" +
 	"\n" +
-	"group_code\x18\x04 \x01(\tR\tgroupCode\x12\x1b\n" +
-	"\tuser_uuid\x18\x05 \x01(\tR\buserUuidB\r\n" +
+	"group_code\x18\x04 \x01(\tB+\xbaH(r&2$^\\p{L}{2,3}\\-[0-9]{1,2}\\-[0-9]{1,2}$R\tgroupCode\x12%\n" +
+	"\tuser_uuid\x18\x05 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\buserUuidB\r\n" +
 	"\v_patronymic\"I\n" +
 	"\x19CreateUserDetailsResponse\x12,\n" +
-	"\adetails\x18\x01 \x01(\v2\x12.proto.UserDetailsR\adetails\"H\n" +
-	"\x15UpdateUserNameRequest\x12\x1b\n" +
-	"\tuser_uuid\x18\x01 \x01(\tR\buserUuid\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\"F\n" +
+	"\adetails\x18\x01 \x01(\v2\x12.proto.UserDetailsR\adetails\"l\n" +
+	"\x15UpdateUserNameRequest\x12%\n" +
+	"\tuser_uuid\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\buserUuid\x12,\n" +
+	"\x04name\x18\x02 \x01(\tB\x18\xbaH\x15r\x132\x11^[\\p{L}\\_\\-\\. ]+$R\x04name\"F\n" +
 	"\x16UpdateUserNameResponse\x12,\n" +
-	"\adetails\x18\x01 \x01(\v2\x12.proto.UserDetailsR\adetails\"Q\n" +
-	"\x18UpdateUserSurnameRequest\x12\x1b\n" +
-	"\tuser_uuid\x18\x01 \x01(\tR\buserUuid\x12\x18\n" +
-	"\asurname\x18\x02 \x01(\tR\asurname\"I\n" +
+	"\adetails\x18\x01 \x01(\v2\x12.proto.UserDetailsR\adetails\"u\n" +
+	"\x18UpdateUserSurnameRequest\x12%\n" +
+	"\tuser_uuid\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\buserUuid\x122\n" +
+	"\asurname\x18\x02 \x01(\tB\x18\xbaH\x15r\x132\x11^[\\p{L}\\_\\-\\. ]+$R\asurname\"I\n" +
 	"\x19UpdateUserSurnameResponse\x12,\n" +
-	"\adetails\x18\x01 \x01(\v2\x12.proto.UserDetailsR\adetails\"Z\n" +
-	"\x1bUpdateUserPatronymicRequest\x12\x1b\n" +
-	"\tuser_uuid\x18\x01 \x01(\tR\buserUuid\x12\x1e\n" +
+	"\adetails\x18\x01 \x01(\v2\x12.proto.UserDetailsR\adetails\"~\n" +
+	"\x1bUpdateUserPatronymicRequest\x12%\n" +
+	"\tuser_uuid\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\buserUuid\x128\n" +
 	"\n" +
-	"patronymic\x18\x02 \x01(\tR\n" +
+	"patronymic\x18\x02 \x01(\tB\x18\xbaH\x15r\x132\x11^[\\p{L}\\_\\-\\. ]+$R\n" +
 	"patronymic\"L\n" +
 	"\x1cUpdateUserPatronymicResponse\x12,\n" +
-	"\adetails\x18\x01 \x01(\v2\x12.proto.UserDetailsR\adetails\"X\n" +
-	"\x1aUpdateUserGroupCodeRequest\x12\x1b\n" +
-	"\tuser_uuid\x18\x01 \x01(\tR\buserUuid\x12\x1d\n" +
+	"\adetails\x18\x01 \x01(\v2\x12.proto.UserDetailsR\adetails\"\x8f\x01\n" +
+	"\x1aUpdateUserGroupCodeRequest\x12%\n" +
+	"\tuser_uuid\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\buserUuid\x12J\n" +
 	"\n" +
-	"group_code\x18\x02 \x01(\tR\tgroupCode\"K\n" +
+	"group_code\x18\x02 \x01(\tB+\xbaH(r&2$^\\p{L}{2,3}\\-[0-9]{1,2}\\-[0-9]{1,2}$R\tgroupCode\"K\n" +
 	"\x1bUpdateUserGroupCodeResponse\x12,\n" +
-	"\adetails\x18\x01 \x01(\v2\x12.proto.UserDetailsR\adetails\"\xb6\x01\n" +
-	"\x19CreateUserContactsRequest\x12!\n" +
-	"\fphone_number\x18\x01 \x01(\tR\vphoneNumber\x12\x19\n" +
-	"\x05email\x18\x02 \x01(\tH\x00R\x05email\x88\x01\x01\x12$\n" +
-	"\vtelegram_id\x18\x03 \x01(\x03H\x01R\n" +
-	"telegramId\x88\x01\x01\x12\x1b\n" +
-	"\tuser_uuid\x18\x04 \x01(\tR\buserUuidB\b\n" +
+	"\adetails\x18\x01 \x01(\v2\x12.proto.UserDetailsR\adetails\"\xec\x01\n" +
+	"\x19CreateUserContactsRequest\x12;\n" +
+	"\fphone_number\x18\x01 \x01(\tB\x18\xbaH\x15r\x132\x11^\\+[1-9]\\d{1,14}$R\vphoneNumber\x12\"\n" +
+	"\x05email\x18\x02 \x01(\tB\a\xbaH\x04r\x02`\x01H\x00R\x05email\x88\x01\x01\x12-\n" +
+	"\vtelegram_id\x18\x03 \x01(\x03B\a\xbaH\x04\"\x02 \x00H\x01R\n" +
+	"telegramId\x88\x01\x01\x12%\n" +
+	"\tuser_uuid\x18\x04 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\buserUuidB\b\n" +
 	"\x06_emailB\x0e\n" +
 	"\f_telegram_id\"M\n" +
 	"\x1aCreateUserContactsResponse\x12/\n" +
-	"\bcontacts\x18\x01 \x01(\v2\x13.proto.UserContactsR\bcontacts\"^\n" +
-	"\x1cUpdateUserPhoneNumberRequest\x12\x1b\n" +
-	"\tuser_uuid\x18\x01 \x01(\tR\buserUuid\x12!\n" +
-	"\fphone_number\x18\x02 \x01(\tR\vphoneNumber\"P\n" +
+	"\bcontacts\x18\x01 \x01(\v2\x13.proto.UserContactsR\bcontacts\"\x82\x01\n" +
+	"\x1cUpdateUserPhoneNumberRequest\x12%\n" +
+	"\tuser_uuid\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\buserUuid\x12;\n" +
+	"\fphone_number\x18\x02 \x01(\tB\x18\xbaH\x15r\x132\x11^\\+[1-9]\\d{1,14}$R\vphoneNumber\"P\n" +
 	"\x1dUpdateUserPhoneNumberResponse\x12/\n" +
-	"\bcontacts\x18\x01 \x01(\v2\x13.proto.UserContactsR\bcontacts\"K\n" +
-	"\x16UpdateUserEmailRequest\x12\x1b\n" +
-	"\tuser_uuid\x18\x01 \x01(\tR\buserUuid\x12\x14\n" +
-	"\x05email\x18\x02 \x01(\tR\x05email\"J\n" +
+	"\bcontacts\x18\x01 \x01(\v2\x13.proto.UserContactsR\bcontacts\"^\n" +
+	"\x16UpdateUserEmailRequest\x12%\n" +
+	"\tuser_uuid\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\buserUuid\x12\x1d\n" +
+	"\x05email\x18\x02 \x01(\tB\a\xbaH\x04r\x02`\x01R\x05email\"J\n" +
 	"\x17UpdateUserEmailResponse\x12/\n" +
-	"\bcontacts\x18\x01 \x01(\v2\x13.proto.UserContactsR\bcontacts\"[\n" +
-	"\x1bUpdateUserTelegramIDRequest\x12\x1b\n" +
-	"\tuser_uuid\x18\x01 \x01(\tR\buserUuid\x12\x1f\n" +
-	"\vtelegram_id\x18\x02 \x01(\x03R\n" +
+	"\bcontacts\x18\x01 \x01(\v2\x13.proto.UserContactsR\bcontacts\"n\n" +
+	"\x1bUpdateUserTelegramIDRequest\x12%\n" +
+	"\tuser_uuid\x18\x01 \x01(\tB\b\xbaH\x05r\x03\xb0\x01\x01R\buserUuid\x12(\n" +
+	"\vtelegram_id\x18\x02 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\n" +
 	"telegramId\"O\n" +
 	"\x1cUpdateUserTelegramIDResponse\x12/\n" +
-	"\bcontacts\x18\x01 \x01(\v2\x13.proto.UserContactsR\bcontacts2\xe4\b\n" +
-	"\vUserService\x12A\n" +
+	"\bcontacts\x18\x01 \x01(\v2\x13.proto.UserContactsR\bcontacts2\xab\r\n" +
+	"\vUserService\x12W\n" +
 	"\n" +
-	"CreateUser\x12\x18.proto.CreateUserRequest\x1a\x19.proto.CreateUserResponse\x12M\n" +
-	"\x0eGetUserDetails\x12\x1c.proto.GetUserDetailsRequest\x1a\x1d.proto.GetUserDetailsResponse\x12P\n" +
-	"\x0fGetUserContacts\x12\x1d.proto.GetUserContactsRequest\x1a\x1e.proto.GetUserContactsResponse\x12A\n" +
+	"CreateUser\x12\x18.proto.CreateUserRequest\x1a\x19.proto.CreateUserResponse\"\x14\x82\xd3\xe4\x93\x02\x0e:\x01*\"\t/v1/users\x12t\n" +
+	"\x0eGetUserDetails\x12\x1c.proto.GetUserDetailsRequest\x1a\x1d.proto.GetUserDetailsResponse\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/v1/users/{user_uuid}/details\x12x\n" +
+	"\x0fGetUserContacts\x12\x1d.proto.GetUserContactsRequest\x1a\x1e.proto.GetUserContactsResponse\"&\x82\xd3\xe4\x93\x02 \x12\x1e/v1/users/{user_uuid}/contacts\x12[\n" +
 	"\n" +
-	"DeleteUser\x12\x18.proto.DeleteUserRequest\x1a\x19.proto.DeleteUserResponse\x12V\n" +
-	"\x11CreateUserDetails\x12\x1f.proto.CreateUserDetailsRequest\x1a .proto.CreateUserDetailsResponse\x12M\n" +
-	"\x0eUpdateUserName\x12\x1c.proto.UpdateUserNameRequest\x1a\x1d.proto.UpdateUserNameResponse\x12V\n" +
-	"\x11UpdateUserSurname\x12\x1f.proto.UpdateUserSurnameRequest\x1a .proto.UpdateUserSurnameResponse\x12_\n" +
-	"\x14UpdateUserPatronymic\x12\".proto.UpdateUserPatronymicRequest\x1a#.proto.UpdateUserPatronymicResponse\x12\\\n" +
-	"\x13UpdateUserGroupCode\x12!.proto.UpdateUserGroupCodeRequest\x1a\".proto.UpdateUserGroupCodeResponse\x12Y\n" +
-	"\x12CreateUserContacts\x12 .proto.CreateUserContactsRequest\x1a!.proto.CreateUserContactsResponse\x12b\n" +
-	"\x15UpdateUserPhoneNumber\x12#.proto.UpdateUserPhoneNumberRequest\x1a$.proto.UpdateUserPhoneNumberResponse\x12P\n" +
-	"\x0fUpdateUserEmail\x12\x1d.proto.UpdateUserEmailRequest\x1a\x1e.proto.UpdateUserEmailResponse\x12_\n" +
-	"\x14UpdateUserTelegramID\x12\".proto.UpdateUserTelegramIDRequest\x1a#.proto.UpdateUserTelegramIDResponseB Z\x1elabgrab/user_service/api/protob\x06proto3"
+	"DeleteUser\x12\x18.proto.DeleteUserRequest\x1a\x19.proto.DeleteUserResponse\"\x18\x82\xd3\xe4\x93\x02\x12*\x10/v1/users/{uuid}\x12\x80\x01\n" +
+	"\x11CreateUserDetails\x12\x1f.proto.CreateUserDetailsRequest\x1a .proto.CreateUserDetailsResponse\"(\x82\xd3\xe4\x93\x02\":\x01*\"\x1d/v1/users/{user_uuid}/details\x12|\n" +
+	"\x0eUpdateUserName\x12\x1c.proto.UpdateUserNameRequest\x1a\x1d.proto.UpdateUserNameResponse\"-\x82\xd3\xe4\x93\x02':\x01*2\"/v1/users/{user_uuid}/details/name\x12\x88\x01\n" +
+	"\x11UpdateUserSurname\x12\x1f.proto.UpdateUserSurnameRequest\x1a .proto.UpdateUserSurnameResponse\"0\x82\xd3\xe4\x93\x02*:\x01*2%/v1/users/{user_uuid}/details/surname\x12\x94\x01\n" +
+	"\x14UpdateUserPatronymic\x12\".proto.UpdateUserPatronymicRequest\x1a#.proto.UpdateUserPatronymicResponse\"3\x82\xd3\xe4\x93\x02-:\x01*2(/v1/users/{user_uuid}/details/patronymic\x12\x91\x01\n" +
+	"\x13UpdateUserGroupCode\x12!.proto.UpdateUserGroupCodeRequest\x1a\".proto.UpdateUserGroupCodeResponse\"3\x82\xd3\xe4\x93\x02-:\x01*2(/v1/users/{user_uuid}/details/group_code\x12\x84\x01\n" +
+	"\x12CreateUserContacts\x12 .proto.CreateUserContactsRequest\x1a!.proto.CreateUserContactsResponse\")\x82\xd3\xe4\x93\x02#:\x01*\"\x1e/v1/users/{user_uuid}/contacts\x12\x9a\x01\n" +
+	"\x15UpdateUserPhoneNumber\x12#.proto.UpdateUserPhoneNumberRequest\x1a$.proto.UpdateUserPhoneNumberResponse\"6\x82\xd3\xe4\x93\x020:\x01*2+/v1/users/{user_uuid}/contacts/phone_number\x12\x81\x01\n" +
+	"\x0fUpdateUserEmail\x12\x1d.proto.UpdateUserEmailRequest\x1a\x1e.proto.UpdateUserEmailResponse\"/\x82\xd3\xe4\x93\x02):\x01*2$/v1/users/{user_uuid}/contacts/email\x12\x96\x01\n" +
+	"\x14UpdateUserTelegramID\x12\".proto.UpdateUserTelegramIDRequest\x1a#.proto.UpdateUserTelegramIDResponse\"5\x82\xd3\xe4\x93\x02/:\x01*2*/v1/users/{user_uuid}/contacts/telegram_idB Z\x1elabgrab/user_service/api/protob\x06proto3"
 
 var (
 	file_user_proto_rawDescOnce sync.Once
@@ -1,7 +1,7 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
 // versions:
 // - protoc-gen-go-grpc v1.6.0
-// - protoc             v6.33.2
+// - protoc             (unknown)
 // source: user.proto
 
 package proto
@@ -38,18 +38,15 @@ const (
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type UserServiceClient interface {
-	// User management
 	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*CreateUserResponse, error)
 	GetUserDetails(ctx context.Context, in *GetUserDetailsRequest, opts ...grpc.CallOption) (*GetUserDetailsResponse, error)
 	GetUserContacts(ctx context.Context, in *GetUserContactsRequest, opts ...grpc.CallOption) (*GetUserContactsResponse, error)
 	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*DeleteUserResponse, error)
-	// User details management
 	CreateUserDetails(ctx context.Context, in *CreateUserDetailsRequest, opts ...grpc.CallOption) (*CreateUserDetailsResponse, error)
 	UpdateUserName(ctx context.Context, in *UpdateUserNameRequest, opts ...grpc.CallOption) (*UpdateUserNameResponse, error)
 	UpdateUserSurname(ctx context.Context, in *UpdateUserSurnameRequest, opts ...grpc.CallOption) (*UpdateUserSurnameResponse, error)
 	UpdateUserPatronymic(ctx context.Context, in *UpdateUserPatronymicRequest, opts ...grpc.CallOption) (*UpdateUserPatronymicResponse, error)
 	UpdateUserGroupCode(ctx context.Context, in *UpdateUserGroupCodeRequest, opts ...grpc.CallOption) (*UpdateUserGroupCodeResponse, error)
-	// User contacts management
 	CreateUserContacts(ctx context.Context, in *CreateUserContactsRequest, opts ...grpc.CallOption) (*CreateUserContactsResponse, error)
 	UpdateUserPhoneNumber(ctx context.Context, in *UpdateUserPhoneNumberRequest, opts ...grpc.CallOption) (*UpdateUserPhoneNumberResponse, error)
 	UpdateUserEmail(ctx context.Context, in *UpdateUserEmailRequest, opts ...grpc.CallOption) (*UpdateUserEmailResponse, error)
@@ -198,18 +195,15 @@ func (c *userServiceClient) UpdateUserTelegramID(ctx context.Context, in *Update
 // All implementations must embed UnimplementedUserServiceServer
 // for forward compatibility.
 type UserServiceServer interface {
-	// User management
 	CreateUser(context.Context, *CreateUserRequest) (*CreateUserResponse, error)
 	GetUserDetails(context.Context, *GetUserDetailsRequest) (*GetUserDetailsResponse, error)
 	GetUserContacts(context.Context, *GetUserContactsRequest) (*GetUserContactsResponse, error)
 	DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error)
-	// User details management
 	CreateUserDetails(context.Context, *CreateUserDetailsRequest) (*CreateUserDetailsResponse, error)
 	UpdateUserName(context.Context, *UpdateUserNameRequest) (*UpdateUserNameResponse, error)
 	UpdateUserSurname(context.Context, *UpdateUserSurnameRequest) (*UpdateUserSurnameResponse, error)
 	UpdateUserPatronymic(context.Context, *UpdateUserPatronymicRequest) (*UpdateUserPatronymicResponse, error)
 	UpdateUserGroupCode(context.Context, *UpdateUserGroupCodeRequest) (*UpdateUserGroupCodeResponse, error)
-	// User contacts management
 	CreateUserContacts(context.Context, *CreateUserContactsRequest) (*CreateUserContactsResponse, error)
 	UpdateUserPhoneNumber(context.Context, *UpdateUserPhoneNumberRequest) (*UpdateUserPhoneNumberResponse, error)
 	UpdateUserEmail(context.Context, *UpdateUserEmailRequest) (*UpdateUserEmailResponse, error)
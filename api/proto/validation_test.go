@@ -1,11 +1,22 @@
-package service_test
+package proto_test
 
 import (
-	"labgrab/user_service/internal/service"
 	"testing"
+
+	"buf.build/go/protovalidate"
+	protobuf "google.golang.org/protobuf/proto"
+
+	"labgrab/user_service/api/proto"
 )
 
-func TestValidateAlphabeticString(t *testing.T) {
+const validUUID = "e4b2a4a4-9a6e-4d0b-8f8e-6f1f3f0c2a11"
+
+func valid(t *testing.T, msg protobuf.Message) bool {
+	t.Helper()
+	return protovalidate.Validate(msg) == nil
+}
+
+func TestAlphabeticStringRules(t *testing.T) {
 	tests := []struct {
 		name  string
 		input string
@@ -38,15 +49,15 @@ func TestValidateAlphabeticString(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := service.ValidateAlphabeticString(tt.input)
+			got := valid(t, &proto.UpdateUserNameRequest{UserUuid: validUUID, Name: tt.input})
 			if got != tt.want {
-				t.Errorf("ValidateAlphabeticString(%q) = %v, want %v", tt.input, got, tt.want)
+				t.Errorf("UpdateUserNameRequest{Name: %q} valid = %v, want %v", tt.input, got, tt.want)
 			}
 		})
 	}
 }
 
-func TestValidateGroupCode(t *testing.T) {
+func TestGroupCodeRules(t *testing.T) {
 	tests := []struct {
 		name  string
 		input string
@@ -75,15 +86,15 @@ func TestValidateGroupCode(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := service.ValidateGroupCode(tt.input)
+			got := valid(t, &proto.UpdateUserGroupCodeRequest{UserUuid: validUUID, GroupCode: tt.input})
 			if got != tt.want {
-				t.Errorf("ValidateGroupCode(%q) = %v, want %v", tt.input, got, tt.want)
+				t.Errorf("UpdateUserGroupCodeRequest{GroupCode: %q} valid = %v, want %v", tt.input, got, tt.want)
 			}
 		})
 	}
 }
 
-func TestValidatePhoneNumber(t *testing.T) {
+func TestPhoneNumberRules(t *testing.T) {
 	tests := []struct {
 		name  string
 		input string
@@ -114,18 +125,18 @@ func TestValidatePhoneNumber(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := service.ValidatePhoneNumber(tt.input)
+			got := valid(t, &proto.UpdateUserPhoneNumberRequest{UserUuid: validUUID, PhoneNumber: tt.input})
 			if got != tt.want {
-				t.Errorf("ValidatePhoneNumber(%q) = %v, want %v", tt.input, got, tt.want)
+				t.Errorf("UpdateUserPhoneNumberRequest{PhoneNumber: %q} valid = %v, want %v", tt.input, got, tt.want)
 			}
 		})
 	}
 }
 
-func TestValidateTelegramID(t *testing.T) {
+func TestTelegramIDRules(t *testing.T) {
 	tests := []struct {
 		name  string
-		input int
+		input int64
 		want  bool
 	}{
 		{name: "valid ID", input: 1234567890, want: true},
@@ -136,10 +147,69 @@ func TestValidateTelegramID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := service.ValidateTelegramID(tt.input)
+			got := valid(t, &proto.UpdateUserTelegramIDRequest{UserUuid: validUUID, TelegramId: tt.input})
+			if got != tt.want {
+				t.Errorf("UpdateUserTelegramIDRequest{TelegramId: %d} valid = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmailRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "simple", input: "ivanov@example.com", want: true},
+		{name: "subdomain", input: "i.ivanov@student.example.edu", want: true},
+		{name: "plus tag", input: "ivanov+labs@example.com", want: true},
+
+		{name: "empty string", input: "", want: false},
+		{name: "no at sign", input: "ivanov.example.com", want: false},
+		{name: "no domain", input: "ivanov@", want: false},
+		{name: "with display name", input: "Ivanov <ivanov@example.com>", want: false},
+		{name: "with spaces", input: "ivan ov@example.com", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := valid(t, &proto.UpdateUserEmailRequest{UserUuid: validUUID, Email: tt.input})
 			if got != tt.want {
-				t.Errorf("ValidateTelegramID(%d) = %v, want %v", tt.input, got, tt.want)
+				t.Errorf("UpdateUserEmailRequest{Email: %q} valid = %v, want %v", tt.input, got, tt.want)
 			}
 		})
 	}
 }
+
+func TestUUIDRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "lowercase", input: validUUID, want: true},
+		{name: "uppercase", input: "E4B2A4A4-9A6E-4D0B-8F8E-6F1F3F0C2A11", want: true},
+
+		{name: "empty string", input: "", want: false},
+		{name: "no hyphens", input: "e4b2a4a49a6e4d0b8f8e6f1f3f0c2a11", want: false},
+		{name: "too short", input: "e4b2a4a4-9a6e-4d0b-8f8e", want: false},
+		{name: "not hex", input: "z4b2a4a4-9a6e-4d0b-8f8e-6f1f3f0c2a11", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := valid(t, &proto.DeleteUserRequest{Uuid: tt.input})
+			if got != tt.want {
+				t.Errorf("DeleteUserRequest{Uuid: %q} valid = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionalFieldsAreSkippedWhenUnset(t *testing.T) {
+	req := &proto.CreateUserContactsRequest{UserUuid: validUUID, PhoneNumber: "+79991234567"}
+	if err := protovalidate.Validate(req); err != nil {
+		t.Errorf("CreateUserContactsRequest without optional fields: %v", err)
+	}
+}
@@ -0,0 +1,522 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+
+	"labgrab/user_service/api/proto"
+)
+
+func runCreate(ctx context.Context, a *app, args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: " + commands["create"].usage)
+	}
+	id := uuid.NewString()
+	if len(args) == 1 {
+		id = args[0]
+	}
+
+	req := &proto.CreateUserRequest{Uuid: id}
+	if err := a.validate(req); err != nil {
+		return err
+	}
+	callCtx, cancel := a.call(ctx)
+	defer cancel()
+	resp, err := a.client.CreateUser(callCtx, req)
+	if err != nil {
+		return err
+	}
+	return a.print(record{UUID: resp.GetUser().GetUuid()})
+}
+
+func runGet(ctx context.Context, a *app, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + commands["get"].usage)
+	}
+	rec, err := a.fetch(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if rec.Details == nil && rec.Contacts == nil {
+		return fmt.Errorf("user %s has no details or contacts", args[0])
+	}
+	return a.print(rec)
+}
+
+func runList(ctx context.Context, a *app, args []string) error {
+	ids, err := readUUIDs(a.in, args)
+	if err != nil {
+		return err
+	}
+	records := make([]record, 0, len(ids))
+	for _, id := range ids {
+		rec, err := a.fetch(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		records = append(records, rec)
+	}
+	return a.print(records...)
+}
+
+func runDelete(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: " + commands["delete"].usage)
+	}
+	id := fs.Arg(0)
+
+	req := &proto.DeleteUserRequest{Uuid: id}
+	if err := a.validate(req); err != nil {
+		return err
+	}
+	if !*yes {
+		rec, err := a.fetch(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := a.print(rec); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "Delete user %s with all details and contacts? [y/N] ", id)
+		answer, _ := bufio.NewReader(a.in).ReadString('\n')
+		if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+			return errors.New("aborted")
+		}
+	}
+
+	callCtx, cancel := a.call(ctx)
+	defer cancel()
+	if _, err := a.client.DeleteUser(callCtx, req); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Deleted user %s\n", id)
+	return nil
+}
+
+func runCreateDetails(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("create-details", flag.ContinueOnError)
+	name := fs.String("name", "", "first name")
+	surname := fs.String("surname", "", "surname")
+	patronymic := fs.String("patronymic", "", "patronymic, optional")
+	group := fs.String("group", "", "group code, e.g. ИУ-7-1")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: " + commands["create-details"].usage)
+	}
+
+	req := &proto.CreateUserDetailsRequest{
+		UserUuid:  fs.Arg(0),
+		Name:      *name,
+		Surname:   *surname,
+		GroupCode: *group,
+	}
+	if *patronymic != "" {
+		req.Patronymic = patronymic
+	}
+	if err := a.validate(req); err != nil {
+		return err
+	}
+	callCtx, cancel := a.call(ctx)
+	defer cancel()
+	if _, err := a.client.CreateUserDetails(callCtx, req); err != nil {
+		return err
+	}
+	return a.show(ctx, req.UserUuid)
+}
+
+func runCreateContacts(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("create-contacts", flag.ContinueOnError)
+	phone := fs.String("phone", "", "phone number in E.164 format")
+	email := fs.String("email", "", "email, optional")
+	telegram := fs.Int64("telegram", 0, "telegram ID, optional")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: " + commands["create-contacts"].usage)
+	}
+
+	req := &proto.CreateUserContactsRequest{
+		UserUuid:    fs.Arg(0),
+		PhoneNumber: *phone,
+	}
+	if *email != "" {
+		req.Email = email
+	}
+	if *telegram != 0 {
+		req.TelegramId = telegram
+	}
+	if err := a.validate(req); err != nil {
+		return err
+	}
+	callCtx, cancel := a.call(ctx)
+	defer cancel()
+	if _, err := a.client.CreateUserContacts(callCtx, req); err != nil {
+		return err
+	}
+	return a.show(ctx, req.UserUuid)
+}
+
+func runUpdate(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "validate the changes without applying them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		return errors.New("usage: " + commands["update"].usage)
+	}
+	id := fs.Arg(0)
+
+	var updates []update
+	for _, arg := range fs.Args()[1:] {
+		field, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("%q must be field=value", arg)
+		}
+		u, err := newUpdate(field, id, value)
+		if err != nil {
+			return err
+		}
+		updates = append(updates, u)
+	}
+
+	if *dryRun {
+		if err := a.validate(requests(updates)...); err != nil {
+			return err
+		}
+		for _, u := range updates {
+			fmt.Fprintf(a.out, "Would set %s = %q\n", u.field, u.value)
+		}
+		return nil
+	}
+	if err := a.apply(ctx, updates); err != nil {
+		return err
+	}
+	return a.show(ctx, id)
+}
+
+func runExport(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	file := fs.String("file", "", "write records to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ids, err := readUUIDs(a.in, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	out := a.out
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *file, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	for _, id := range ids {
+		rec, err := a.fetch(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		if err := writeRecords(out, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runImport(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := fs.String("file", "", "read records from this file instead of stdin")
+	dryRun := fs.Bool("dry-run", false, "validate the records without calling the server")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: " + commands["import"].usage)
+	}
+
+	in := a.in
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", *file, err)
+		}
+		defer f.Close()
+		in = f
+	}
+	records, err := readRecords(in)
+	if err != nil {
+		return err
+	}
+
+	// Everything is validated before the first call, so a typo on the last
+	// line does not leave the import half done.
+	for i, rec := range records {
+		if err := a.validate(rec.createRequests()...); err != nil {
+			return fmt.Errorf("record %d (%s): %w", i+1, rec.UUID, err)
+		}
+	}
+	if *dryRun {
+		fmt.Fprintf(a.out, "%d records are valid\n", len(records))
+		return nil
+	}
+
+	for i, rec := range records {
+		if err := a.importRecord(ctx, rec); err != nil {
+			return fmt.Errorf("record %d (%s): %w", i+1, rec.UUID, err)
+		}
+		fmt.Fprintf(a.out, "Imported user %s\n", rec.UUID)
+	}
+	return nil
+}
+
+// importRecord creates whatever part of rec is missing on the server and
+// updates the parts that already exist.
+func (a *app) importRecord(ctx context.Context, rec record) error {
+	reqs := rec.createRequests()
+
+	callCtx, cancel := a.call(ctx)
+	defer cancel()
+	_, err := a.client.CreateUser(callCtx, reqs[0].(*proto.CreateUserRequest))
+	if err != nil && status.Code(err) != grpccodes.AlreadyExists {
+		return err
+	}
+
+	for _, req := range reqs[1:] {
+		callCtx, cancel := a.call(ctx)
+		switch req := req.(type) {
+		case *proto.CreateUserDetailsRequest:
+			_, err = a.client.CreateUserDetails(callCtx, req)
+		case *proto.CreateUserContactsRequest:
+			_, err = a.client.CreateUserContacts(callCtx, req)
+		}
+		cancel()
+		if status.Code(err) == grpccodes.AlreadyExists {
+			err = a.apply(ctx, rec.updates(req))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type update struct {
+	field string
+	value string
+	req   protobuf.Message
+	send  func(ctx context.Context, c proto.UserServiceClient) error
+}
+
+// fields maps the names accepted by update to the RPC that changes them.
+var fields = map[string]func(id, value string) (update, error){
+	"name": func(id, value string) (update, error) {
+		req := &proto.UpdateUserNameRequest{UserUuid: id, Name: value}
+		return update{req: req, send: func(ctx context.Context, c proto.UserServiceClient) error {
+			_, err := c.UpdateUserName(ctx, req)
+			return err
+		}}, nil
+	},
+	"surname": func(id, value string) (update, error) {
+		req := &proto.UpdateUserSurnameRequest{UserUuid: id, Surname: value}
+		return update{req: req, send: func(ctx context.Context, c proto.UserServiceClient) error {
+			_, err := c.UpdateUserSurname(ctx, req)
+			return err
+		}}, nil
+	},
+	"patronymic": func(id, value string) (update, error) {
+		req := &proto.UpdateUserPatronymicRequest{UserUuid: id, Patronymic: value}
+		return update{req: req, send: func(ctx context.Context, c proto.UserServiceClient) error {
+			_, err := c.UpdateUserPatronymic(ctx, req)
+			return err
+		}}, nil
+	},
+	"group_code": func(id, value string) (update, error) {
+		req := &proto.UpdateUserGroupCodeRequest{UserUuid: id, GroupCode: value}
+		return update{req: req, send: func(ctx context.Context, c proto.UserServiceClient) error {
+			_, err := c.UpdateUserGroupCode(ctx, req)
+			return err
+		}}, nil
+	},
+	"phone_number": func(id, value string) (update, error) {
+		req := &proto.UpdateUserPhoneNumberRequest{UserUuid: id, PhoneNumber: value}
+		return update{req: req, send: func(ctx context.Context, c proto.UserServiceClient) error {
+			_, err := c.UpdateUserPhoneNumber(ctx, req)
+			return err
+		}}, nil
+	},
+	"email": func(id, value string) (update, error) {
+		req := &proto.UpdateUserEmailRequest{UserUuid: id, Email: value}
+		return update{req: req, send: func(ctx context.Context, c proto.UserServiceClient) error {
+			_, err := c.UpdateUserEmail(ctx, req)
+			return err
+		}}, nil
+	},
+	"telegram_id": func(id, value string) (update, error) {
+		telegramID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return update{}, fmt.Errorf("telegram_id must be an integer: %w", err)
+		}
+		req := &proto.UpdateUserTelegramIDRequest{UserUuid: id, TelegramId: telegramID}
+		return update{req: req, send: func(ctx context.Context, c proto.UserServiceClient) error {
+			_, err := c.UpdateUserTelegramID(ctx, req)
+			return err
+		}}, nil
+	},
+}
+
+func newUpdate(field, id, value string) (update, error) {
+	build, ok := fields[field]
+	if !ok {
+		return update{}, fmt.Errorf("unknown field %q, expected one of %s", field, strings.Join(fieldNames(), ", "))
+	}
+	u, err := build(id, value)
+	u.field, u.value = field, value
+	return u, err
+}
+
+func fieldNames() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func requests(updates []update) []protobuf.Message {
+	reqs := make([]protobuf.Message, len(updates))
+	for i, u := range updates {
+		reqs[i] = u.req
+	}
+	return reqs
+}
+
+// apply validates all updates before sending any of them, so an invalid
+// value never leaves a profile partially updated.
+func (a *app) apply(ctx context.Context, updates []update) error {
+	if err := a.validate(requests(updates)...); err != nil {
+		return err
+	}
+	for _, u := range updates {
+		callCtx, cancel := a.call(ctx)
+		err := u.send(callCtx, a.client)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %w", u.field, err)
+		}
+	}
+	return nil
+}
+
+func (a *app) validate(reqs ...protobuf.Message) error {
+	for _, req := range reqs {
+		if err := a.validator.Validate(req); err != nil {
+			return fmt.Errorf("invalid request: %w", err)
+		}
+	}
+	return nil
+}
+
+// fetch reads the details and contacts of a user. Either may be missing.
+func (a *app) fetch(ctx context.Context, id string) (record, error) {
+	rec := record{UUID: id}
+
+	callCtx, cancel := a.call(ctx)
+	defer cancel()
+	details, err := a.client.GetUserDetails(callCtx, &proto.GetUserDetailsRequest{UserUuid: id})
+	switch status.Code(err) {
+	case grpccodes.OK:
+		rec.Details = details.GetDetails()
+	case grpccodes.NotFound:
+	default:
+		return rec, err
+	}
+
+	contacts, err := a.client.GetUserContacts(callCtx, &proto.GetUserContactsRequest{UserUuid: id})
+	switch status.Code(err) {
+	case grpccodes.OK:
+		rec.Contacts = contacts.GetContacts()
+	case grpccodes.NotFound:
+	default:
+		return rec, err
+	}
+	return rec, nil
+}
+
+func (a *app) show(ctx context.Context, id string) error {
+	rec, err := a.fetch(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.print(rec)
+}
+
+// readUUIDs returns args, or the non-empty lines of in when args is empty.
+func readUUIDs(in io.Reader, args []string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+	var ids []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read UUIDs: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no UUIDs given")
+	}
+	return ids, nil
+}
+
+// describe formats err for the terminal, including the field violations of
+// an InvalidArgument status.
+func describe(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err.Error()
+	}
+
+	var b strings.Builder
+	b.WriteString(err.Error())
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				fmt.Fprintf(&b, "\n  %s: %s", v.GetField(), v.GetDescription())
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,205 @@
+// Command userctl is an admin client for the UserService gRPC API.
+//
+// The API has no List RPC, so list and export work on UUIDs passed as
+// arguments or read one per line from stdin.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"buf.build/go/protovalidate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"labgrab/user_service/api/proto"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, a *app, args []string) error
+}
+
+// commands is filled in init because the commands print their own usage.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"create":          {"create [uuid]", runCreate},
+		"get":             {"get <uuid>", runGet},
+		"list":            {"list [uuid...]", runList},
+		"delete":          {"delete [-yes] <uuid>", runDelete},
+		"create-details":  {"create-details -name N -surname S [-patronymic P] -group G <uuid>", runCreateDetails},
+		"create-contacts": {"create-contacts -phone P [-email E] [-telegram ID] <uuid>", runCreateContacts},
+		"update":          {"update [-dry-run] <uuid> field=value...", runUpdate},
+		"export":          {"export [-file F] [uuid...]", runExport},
+		"import":          {"import [-file F] [-dry-run]", runImport},
+	}
+}
+
+type headers []string
+
+func (h *headers) String() string { return strings.Join(*h, ", ") }
+
+func (h *headers) Set(v string) error {
+	if _, _, ok := strings.Cut(v, ":"); !ok {
+		return fmt.Errorf("header %q must be \"key: value\"", v)
+	}
+	*h = append(*h, v)
+	return nil
+}
+
+type app struct {
+	client    proto.UserServiceClient
+	validator protovalidate.Validator
+	in        io.Reader
+	out       io.Writer
+	format    string
+	timeout   time.Duration
+	md        metadata.MD
+}
+
+// call returns a context for a single RPC carrying the timeout and the
+// outgoing metadata.
+func (a *app) call(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(metadata.NewOutgoingContext(ctx, a.md), a.timeout)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("userctl: ")
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		log.Fatal(describe(err))
+	}
+}
+
+func run(ctx context.Context, args []string, in io.Reader, out io.Writer, opts ...grpc.DialOption) error {
+	fs := flag.NewFlagSet("userctl", flag.ContinueOnError)
+	fs.Usage = func() { usage(fs) }
+
+	addr := fs.String("addr", envOr("USERCTL_ADDR", "localhost:50051"), "server address")
+	useTLS := fs.Bool("tls", false, "connect with TLS")
+	caFile := fs.String("ca", "", "CA bundle to verify the server with (implies -tls)")
+	certFile := fs.String("cert", "", "client certificate for servers requiring one (implies -tls, needs -key)")
+	keyFile := fs.String("key", "", "private key of the client certificate")
+	serverName := fs.String("server-name", "", "override the TLS server name")
+	token := fs.String("token", os.Getenv("USERCTL_TOKEN"), "bearer token sent as authorization metadata")
+	format := fs.String("o", "table", "output format: table or json")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout for each call")
+	var hdrs headers
+	fs.Var(&hdrs, "H", "extra metadata as \"key: value\", repeatable")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no command given")
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fs.Usage()
+		return fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+
+	md := metadata.MD{}
+	if *token != "" {
+		md.Append("authorization", "Bearer "+*token)
+	}
+	for _, h := range hdrs {
+		k, v, _ := strings.Cut(h, ":")
+		md.Append(strings.TrimSpace(k), strings.TrimSpace(v))
+	}
+
+	if (*certFile == "") != (*keyFile == "") {
+		return errors.New("-cert and -key must be given together")
+	}
+
+	creds := insecure.NewCredentials()
+	if *useTLS || *caFile != "" || *certFile != "" {
+		tlsConfig := &tls.Config{ServerName: *serverName}
+		if *certFile != "" {
+			cert, err := tls.LoadX509KeyPair(*certFile, *keyFile)
+			if err != nil {
+				return fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		if *caFile != "" {
+			pem, err := os.ReadFile(*caFile)
+			if err != nil {
+				return fmt.Errorf("failed to read CA bundle: %w", err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+				return fmt.Errorf("no certificates found in %s", *caFile)
+			}
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(*addr, append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, opts...)...)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", *addr, err)
+	}
+	defer conn.Close()
+
+	validator, err := protovalidate.New()
+	if err != nil {
+		return fmt.Errorf("failed to create request validator: %w", err)
+	}
+
+	return cmd.run(ctx, &app{
+		client:    proto.NewUserServiceClient(conn),
+		validator: validator,
+		in:        in,
+		out:       out,
+		format:    *format,
+		timeout:   *timeout,
+		md:        md,
+	}, fs.Args()[1:])
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintln(w, "usage: userctl [flags] <command> [args]")
+	fmt.Fprintln(w, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintf(w, "\nupdatable fields: %s\n", strings.Join(fieldNames(), ", "))
+	fmt.Fprintln(w, "\nflags:")
+	fs.PrintDefaults()
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"labgrab/user_service/internal/testserver"
+)
+
+const testUUID = "0b7f9c1e-4c1a-4d6e-9f6b-2a8d3c5e7f10"
+
+// newServer starts the service on an in-memory listener and returns a
+// function running userctl against it.
+func newServer(t *testing.T) func(stdin string, args ...string) (string, error) {
+	t.Helper()
+
+	dialer := testserver.Serve(t, testserver.New(t))
+	return func(stdin string, args ...string) (string, error) {
+		var out bytes.Buffer
+		args = append([]string{"-addr", testserver.Target}, args...)
+		err := run(context.Background(), args, strings.NewReader(stdin), &out,
+			dialer, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		return out.String(), err
+	}
+}
+
+func TestCreateUpdateGet(t *testing.T) {
+	userctl := newServer(t)
+
+	steps := [][]string{
+		{"create", testUUID},
+		{"create-details", "-name", "Иван", "-surname", "Иванов", "-group", "ИУ-7-1", testUUID},
+		{"create-contacts", "-phone", "+79990001122", testUUID},
+		{"update", testUUID, "email=ivan@example.com", "telegram_id=42"},
+	}
+	for _, args := range steps {
+		if out, err := userctl("", args...); err != nil {
+			t.Fatalf("userctl %v: %v\n%s", args, err, out)
+		}
+	}
+
+	out, err := userctl("", "-o", "json", "get", testUUID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	want := `{"uuid":"` + testUUID + `","details":{"name":"Иван","surname":"Иванов","group_code":"ИУ-7-1","user_uuid":"` + testUUID +
+		`"},"contacts":{"phone_number":"+79990001122","email":"ivan@example.com","telegram_id":"42","user_uuid":"` + testUUID + `"}}` + "\n"
+	if out != want {
+		t.Errorf("get = %s, want %s", out, want)
+	}
+}
+
+func TestUpdateValidatesAllFieldsFirst(t *testing.T) {
+	userctl := newServer(t)
+
+	for _, args := range [][]string{
+		{"create", testUUID},
+		{"create-details", "-name", "Иван", "-surname", "Иванов", "-group", "ИУ-7-1", testUUID},
+	} {
+		if _, err := userctl("", args...); err != nil {
+			t.Fatalf("userctl %v: %v", args, err)
+		}
+	}
+
+	if _, err := userctl("", "update", testUUID, "name=Пётр", "group_code=bad"); err == nil {
+		t.Fatal("update with an invalid group code succeeded")
+	}
+
+	out, err := userctl("", "get", testUUID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !strings.Contains(out, "Иван ") {
+		t.Errorf("name changed although the update was rejected:\n%s", out)
+	}
+}
+
+func TestDeleteAsksForConfirmation(t *testing.T) {
+	userctl := newServer(t)
+
+	if _, err := userctl("", "create", testUUID); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := userctl("n\n", "delete", testUUID); err == nil || !strings.Contains(err.Error(), "aborted") {
+		t.Fatalf("delete answered with n: err = %v, want aborted", err)
+	}
+	if _, err := userctl("", "create", testUUID); err == nil {
+		t.Fatal("user was deleted although the deletion was not confirmed")
+	}
+	if _, err := userctl("y\n", "delete", testUUID); err != nil {
+		t.Fatalf("delete answered with y: %v", err)
+	}
+	if _, err := userctl("", "create", testUUID); err != nil {
+		t.Errorf("user still exists after a confirmed delete: %v", err)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	source := newServer(t)
+	for _, args := range [][]string{
+		{"create", testUUID},
+		{"create-details", "-name", "Иван", "-surname", "Иванов", "-patronymic", "Иванович", "-group", "ИУ-7-1", testUUID},
+		{"create-contacts", "-phone", "+79990001122", "-telegram", "42", testUUID},
+	} {
+		if _, err := source("", args...); err != nil {
+			t.Fatalf("userctl %v: %v", args, err)
+		}
+	}
+	exported, err := source(testUUID+"\n", "export")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	target := newServer(t)
+	if _, err := target("", "create", testUUID); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := target("", "create-details", "-name", "Пётр", "-surname", "Петров", "-group", "ИУ-1-1", testUUID); err != nil {
+		t.Fatalf("create-details: %v", err)
+	}
+	if out, err := target(exported, "import"); err != nil {
+		t.Fatalf("import: %v\n%s", err, out)
+	}
+
+	got, err := target("", "export", testUUID)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if got != exported {
+		t.Errorf("after import export = %s, want %s", got, exported)
+	}
+}
+
+func TestImportRejectsInvalidRecordsUpFront(t *testing.T) {
+	userctl := newServer(t)
+
+	records := `{"uuid":"` + testUUID + `"}` + "\n" +
+		`{"uuid":"` + testUUID + `","contacts":{"phone_number":"123"}}` + "\n"
+	if _, err := userctl(records, "import"); err == nil || !strings.Contains(err.Error(), "record 2") {
+		t.Fatalf("import: err = %v, want error for record 2", err)
+	}
+	if _, err := userctl("", "create", testUUID); err != nil {
+		t.Errorf("first record was imported although the second was invalid: %v", err)
+	}
+}
+
+func TestCertNeedsKey(t *testing.T) {
+	userctl := newServer(t)
+
+	if _, err := userctl("", "-cert", "client.pem", "get", testUUID); err == nil || !strings.Contains(err.Error(), "-key") {
+		t.Errorf("-cert without -key: err = %v, want an error naming -key", err)
+	}
+}
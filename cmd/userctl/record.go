@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	protobuf "google.golang.org/protobuf/proto"
+
+	"labgrab/user_service/api/proto"
+)
+
+// record is everything the API stores about one user. It is the unit of
+// the JSON output and of the export/import format, one record per line.
+type record struct {
+	UUID     string
+	Details  *proto.UserDetails
+	Contacts *proto.UserContacts
+}
+
+type recordJSON struct {
+	UUID     string          `json:"uuid"`
+	Details  json.RawMessage `json:"details,omitempty"`
+	Contacts json.RawMessage `json:"contacts,omitempty"`
+}
+
+var (
+	marshalOptions   = protojson.MarshalOptions{UseProtoNames: true}
+	unmarshalOptions = protojson.UnmarshalOptions{}
+)
+
+func (r record) MarshalJSON() ([]byte, error) {
+	out := recordJSON{UUID: r.UUID}
+	var err error
+	if r.Details != nil {
+		if out.Details, err = marshalOptions.Marshal(r.Details); err != nil {
+			return nil, err
+		}
+	}
+	if r.Contacts != nil {
+		if out.Contacts, err = marshalOptions.Marshal(r.Contacts); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(out)
+}
+
+func (r *record) UnmarshalJSON(data []byte) error {
+	var in recordJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*r = record{UUID: in.UUID}
+	if in.Details != nil {
+		r.Details = &proto.UserDetails{}
+		if err := unmarshalOptions.Unmarshal(in.Details, r.Details); err != nil {
+			return fmt.Errorf("details: %w", err)
+		}
+	}
+	if in.Contacts != nil {
+		r.Contacts = &proto.UserContacts{}
+		if err := unmarshalOptions.Unmarshal(in.Contacts, r.Contacts); err != nil {
+			return fmt.Errorf("contacts: %w", err)
+		}
+	}
+	return nil
+}
+
+// createRequests returns the requests that create the record from scratch.
+// The first one is always the CreateUserRequest.
+func (r record) createRequests() []protobuf.Message {
+	reqs := []protobuf.Message{&proto.CreateUserRequest{Uuid: r.UUID}}
+	if d := r.Details; d != nil {
+		reqs = append(reqs, &proto.CreateUserDetailsRequest{
+			UserUuid:   r.UUID,
+			Name:       d.GetName(),
+			Surname:    d.GetSurname(),
+			Patronymic: d.Patronymic,
+			GroupCode:  d.GetGroupCode(),
+		})
+	}
+	if c := r.Contacts; c != nil {
+		reqs = append(reqs, &proto.CreateUserContactsRequest{
+			UserUuid:    r.UUID,
+			PhoneNumber: c.GetPhoneNumber(),
+			Email:       c.Email,
+			TelegramId:  c.TelegramId,
+		})
+	}
+	return reqs
+}
+
+// updates returns the field updates that bring existing data in line with a
+// create request that failed with AlreadyExists.
+func (r record) updates(req protobuf.Message) []update {
+	values := map[string]*string{}
+	switch req := req.(type) {
+	case *proto.CreateUserDetailsRequest:
+		values["name"] = &req.Name
+		values["surname"] = &req.Surname
+		values["patronymic"] = req.Patronymic
+		values["group_code"] = &req.GroupCode
+	case *proto.CreateUserContactsRequest:
+		values["phone_number"] = &req.PhoneNumber
+		values["email"] = req.Email
+		if req.TelegramId != nil {
+			telegramID := strconv.FormatInt(*req.TelegramId, 10)
+			values["telegram_id"] = &telegramID
+		}
+	}
+
+	var updates []update
+	for _, field := range fieldNames() {
+		if v := values[field]; v != nil {
+			// The values come from a validated create request, so building
+			// the update cannot fail.
+			u, _ := newUpdate(field, r.UUID, *v)
+			updates = append(updates, u)
+		}
+	}
+	return updates
+}
+
+func (a *app) print(records ...record) error {
+	if a.format == "json" {
+		return writeRecords(a.out, records...)
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "UUID\tNAME\tSURNAME\tPATRONYMIC\tGROUP CODE\tPHONE NUMBER\tEMAIL\tTELEGRAM ID")
+	for _, r := range records {
+		d, c := r.Details, r.Contacts
+		telegramID := ""
+		if c != nil && c.TelegramId != nil {
+			telegramID = strconv.FormatInt(c.GetTelegramId(), 10)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.UUID,
+			orDash(d.GetName()), orDash(d.GetSurname()), orDash(d.GetPatronymic()), orDash(d.GetGroupCode()),
+			orDash(c.GetPhoneNumber()), orDash(c.GetEmail()), orDash(telegramID))
+	}
+	return w.Flush()
+}
+
+func writeRecords(w io.Writer, records ...record) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to write record %s: %w", r.UUID, err)
+		}
+	}
+	return nil
+}
+
+func readRecords(r io.Reader) ([]record, error) {
+	var records []record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read records: %w", err)
+	}
+	return records, nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
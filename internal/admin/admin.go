@@ -0,0 +1,23 @@
+// Package admin registers the debugging services used by grpcurl, grpcui and
+// similar tools: server reflection and channelz.
+package admin
+
+import (
+	"google.golang.org/grpc"
+	channelzservice "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/reflection"
+	v1reflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	v1alphareflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+// Register adds reflection and channelz to s. Reflection describes the
+// services of target, which is s itself unless the admin services run on a
+// separate listener.
+func Register(s grpc.ServiceRegistrar, target reflection.ServiceInfoProvider) {
+	opts := reflection.ServerOptions{Services: target}
+	v1reflectiongrpc.RegisterServerReflectionServer(s, reflection.NewServerV1(opts))
+	// Older clients only speak v1alpha.
+	v1alphareflectiongrpc.RegisterServerReflectionServer(s, reflection.NewServer(opts))
+
+	channelzservice.RegisterChannelzServiceToServer(s)
+}
@@ -0,0 +1,59 @@
+package admin_test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	channelzpb "google.golang.org/grpc/channelz/grpc_channelz_v1"
+	"google.golang.org/grpc/credentials/insecure"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+
+	"labgrab/user_service/api/proto"
+	"labgrab/user_service/internal/admin"
+	"labgrab/user_service/internal/testserver"
+)
+
+func TestSeparateListenerReflectsMainServer(t *testing.T) {
+	target := grpc.NewServer()
+	proto.RegisterUserServiceServer(target, proto.UnimplementedUserServiceServer{})
+
+	adminServer := grpc.NewServer()
+	admin.Register(adminServer, target)
+
+	conn, err := grpc.NewClient(testserver.Target,
+		testserver.Serve(t, adminServer),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer conn.Close()
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatalf("ServerReflectionInfo: %v", err)
+	}
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+
+	var services []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		services = append(services, s.GetName())
+	}
+	if len(services) != 1 || services[0] != proto.UserService_ServiceDesc.ServiceName {
+		t.Errorf("reflected services = %v, want only %s", services, proto.UserService_ServiceDesc.ServiceName)
+	}
+
+	if _, err := channelzpb.NewChannelzClient(conn).GetServers(context.Background(), &channelzpb.GetServersRequest{}); err != nil {
+		t.Errorf("channelz GetServers: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Package auth verifies bearer JWTs against a JWKS and carries the caller's
+// identity through the request context.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Identity is the authenticated caller.
+type Identity struct {
+	Subject string
+	Roles   []string
+	// ClientSAN names the verified TLS client certificate the call came
+	// with, if any.
+	ClientSAN string
+}
+
+func (i Identity) HasRole(role string) bool {
+	return slices.Contains(i.Roles, role)
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(contextKey{}).(Identity)
+	return identity, ok
+}
+
+type Options struct {
+	// JWKS is a path to a JWK Set file or an http(s) URL serving one.
+	JWKS            string
+	Issuer          string
+	Audience        string
+	RefreshInterval time.Duration
+	Logger          *zap.Logger
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles"`
+}
+
+type keySource interface {
+	keyfunc(ctx context.Context) jwt.Keyfunc
+}
+
+type Verifier struct {
+	keys   keySource
+	parser *jwt.Parser
+}
+
+// NewVerifier loads the JWKS and keeps it up to date until ctx is done:
+// a URL is refetched every RefreshInterval and whenever a token names an
+// unknown key, a file is reloaded when it changes.
+func NewVerifier(ctx context.Context, opts Options) (*Verifier, error) {
+	var (
+		keys keySource
+		err  error
+	)
+	if strings.HasPrefix(opts.JWKS, "http://") || strings.HasPrefix(opts.JWKS, "https://") {
+		keys, err = newURLKeys(ctx, opts.JWKS, opts.RefreshInterval, opts.Logger)
+	} else {
+		keys, err = newFileKeys(ctx, opts.JWKS, opts.RefreshInterval, opts.Logger)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
+
+	return &Verifier{keys: keys, parser: jwt.NewParser(parserOpts...)}, nil
+}
+
+// Verify checks the signature and claims of token and returns the identity
+// it asserts.
+func (v *Verifier) Verify(ctx context.Context, token string) (Identity, error) {
+	var c claims
+	if _, err := v.parser.ParseWithClaims(token, &c, v.keys.keyfunc(ctx)); err != nil {
+		return Identity{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if c.Subject == "" {
+		return Identity{}, fmt.Errorf("%w: no subject", ErrInvalidToken)
+	}
+	return Identity{Subject: c.Subject, Roles: c.Roles}, nil
+}
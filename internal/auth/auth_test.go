@@ -0,0 +1,188 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	"labgrab/user_service/internal/auth"
+)
+
+type key struct {
+	id   string
+	priv ed25519.PrivateKey
+}
+
+func newKey(t *testing.T, id string) key {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return key{id: id, priv: priv}
+}
+
+func (k key) jwks() []byte {
+	x := base64.RawURLEncoding.EncodeToString(k.priv.Public().(ed25519.PublicKey))
+	return []byte(fmt.Sprintf(`{"keys":[{"kty":"OKP","crv":"Ed25519","use":"sig","alg":"EdDSA","kid":%q,"x":%q}]}`, k.id, x))
+}
+
+func (k key) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = k.id
+	signed, err := token.SignedString(k.priv)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "student-1",
+		"iss":   "https://auth.labgrab.test",
+		"aud":   "user-service",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"staff"},
+	}
+}
+
+func writeJWKS(t *testing.T, path string, k key) {
+	t.Helper()
+
+	if err := os.WriteFile(path, k.jwks(), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+func newVerifier(t *testing.T, jwks string, refresh time.Duration) *auth.Verifier {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	v, err := auth.NewVerifier(ctx, auth.Options{
+		JWKS:            jwks,
+		Issuer:          "https://auth.labgrab.test",
+		Audience:        "user-service",
+		RefreshInterval: refresh,
+		Logger:          zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return v
+}
+
+func TestVerify(t *testing.T) {
+	k := newKey(t, "key-1")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, k)
+	v := newVerifier(t, path, time.Hour)
+
+	with := func(name string, value any) jwt.MapClaims {
+		c := validClaims()
+		if value == nil {
+			delete(c, name)
+		} else {
+			c[name] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"valid", k.sign(t, validClaims()), false},
+		{"expired", k.sign(t, with("exp", time.Now().Add(-time.Hour).Unix())), true},
+		{"no expiry", k.sign(t, with("exp", nil)), true},
+		{"wrong issuer", k.sign(t, with("iss", "https://evil.test")), true},
+		{"wrong audience", k.sign(t, with("aud", "other-service")), true},
+		{"no subject", k.sign(t, with("sub", nil)), true},
+		{"unknown key", newKey(t, "key-1").sign(t, validClaims()), true},
+		{"garbage", "not.a.token", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := v.Verify(context.Background(), tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Verify succeeded with identity %+v", identity)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			want := auth.Identity{Subject: "student-1", Roles: []string{"staff"}}
+			if !reflect.DeepEqual(identity, want) {
+				t.Errorf("identity = %+v, want %+v", identity, want)
+			}
+		})
+	}
+}
+
+func TestFileRotation(t *testing.T) {
+	oldKey, newKey := newKey(t, "old"), newKey(t, "new")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, oldKey)
+	v := newVerifier(t, path, 10*time.Millisecond)
+
+	token := newKey.sign(t, validClaims())
+	if _, err := v.Verify(context.Background(), token); err == nil {
+		t.Fatal("token signed with a key not yet in the JWKS was accepted")
+	}
+
+	writeJWKS(t, path, newKey)
+	// Make sure the modification time changes even on coarse filesystems.
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		_, err := v.Verify(context.Background(), token)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("rotated key not picked up: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := v.Verify(context.Background(), oldKey.sign(t, validClaims())); err == nil {
+		t.Error("token signed with the rotated out key was accepted")
+	}
+}
+
+func TestVerifyURL(t *testing.T) {
+	k := newKey(t, "key-1")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(k.jwks())
+	}))
+	defer srv.Close()
+
+	v := newVerifier(t, srv.URL, time.Hour)
+	if _, err := v.Verify(context.Background(), k.sign(t, validClaims())); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
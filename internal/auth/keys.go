@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+type urlKeys struct {
+	keyfunc.Keyfunc
+}
+
+func newURLKeys(ctx context.Context, url string, interval time.Duration, logger *zap.Logger) (*urlKeys, error) {
+	k, err := keyfunc.NewDefaultOverrideCtx(ctx, []string{url}, keyfunc.Override{
+		RefreshInterval: interval,
+		RefreshErrorHandlerFunc: func(u string) func(ctx context.Context, err error) {
+			return func(ctx context.Context, err error) {
+				logger.Error("Failed to refresh JWKS", zap.String("url", u), zap.Error(err))
+			}
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWKS from %s: %w", url, err)
+	}
+	return &urlKeys{k}, nil
+}
+
+func (u *urlKeys) keyfunc(ctx context.Context) jwt.Keyfunc {
+	return u.KeyfuncCtx(ctx)
+}
+
+// fileKeys reloads a JWKS file when its modification time changes. A file
+// that fails to load leaves the previous keys in place.
+type fileKeys struct {
+	path   string
+	logger *zap.Logger
+
+	mu      sync.RWMutex
+	modTime time.Time
+	keys    keyfunc.Keyfunc
+}
+
+func newFileKeys(ctx context.Context, path string, interval time.Duration, logger *zap.Logger) (*fileKeys, error) {
+	f := &fileKeys{path: path, logger: logger}
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := f.reload(); err != nil {
+					logger.Error("Failed to reload JWKS", zap.String("path", path), zap.Error(err))
+				}
+			}
+		}
+	}()
+	return f, nil
+}
+
+func (f *fileKeys) reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	f.mu.RLock()
+	unchanged := info.ModTime().Equal(f.modTime)
+	f.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	keys, err := keyfunc.NewJWKSetJSON(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse JWKS %s: %w", f.path, err)
+	}
+
+	f.mu.Lock()
+	f.keys, f.modTime = keys, info.ModTime()
+	f.mu.Unlock()
+	f.logger.Info("Loaded JWKS", zap.String("path", f.path))
+	return nil
+}
+
+func (f *fileKeys) keyfunc(ctx context.Context) jwt.Keyfunc {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.keys.KeyfuncCtx(ctx)
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/google/uuid"
+
+	"labgrab/user_service/api/proto"
+)
+
+var ErrPermissionDenied = errors.New("permission denied")
+
+// Rule names one way of being allowed to call a method.
+type Rule string
+
+const (
+	// RuleSelf allows callers whose subject is the user_uuid of the request.
+	RuleSelf Rule = "self"
+	// RuleGroupLeader allows callers with the group_leader role acting on a
+	// user of their own group.
+	RuleGroupLeader Rule = "group_leader"
+	// RuleStaff and RuleService allow callers with the role of the same name.
+	RuleStaff   Rule = "staff"
+	RuleService Rule = "service"
+)
+
+// Policy maps UserService method names, e.g. "GetUserDetails", to the rules
+// allowing a call. Methods that are not listed cannot be called.
+type Policy map[string][]Rule
+
+// DefaultPolicy lets students manage their own profile, group leaders and
+// internal services read profiles, and staff do everything. Only staff set
+// group codes: the group leader rule trusts them, so a leader choosing their
+// own group could read the contacts of any group.
+func DefaultPolicy() Policy {
+	read := []Rule{RuleSelf, RuleGroupLeader, RuleStaff, RuleService}
+	write := []Rule{RuleSelf, RuleStaff}
+	return Policy{
+		"CreateUser":            write,
+		"GetUserDetails":        read,
+		"GetUserContacts":       read,
+		"DeleteUser":            {RuleStaff},
+		"CreateUserDetails":     {RuleStaff},
+		"UpdateUserName":        write,
+		"UpdateUserSurname":     write,
+		"UpdateUserPatronymic":  write,
+		"UpdateUserGroupCode":   {RuleStaff},
+		"CreateUserContacts":    write,
+		"UpdateUserPhoneNumber": write,
+		"UpdateUserEmail":       write,
+		"UpdateUserTelegramID":  write,
+	}
+}
+
+// LoadPolicy reads a policy from a JSON file shaped like
+// {"GetUserDetails": ["self", "staff"], ...}.
+func LoadPolicy(file string) (Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", file, err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", file, err)
+	}
+	return policy, nil
+}
+
+func (p Policy) validate() error {
+	methods := map[string]bool{}
+	for _, m := range proto.UserService_ServiceDesc.Methods {
+		methods[m.MethodName] = true
+	}
+	for method, rules := range p {
+		if !methods[method] {
+			return fmt.Errorf("unknown method %q", method)
+		}
+		for _, rule := range rules {
+			switch rule {
+			case RuleSelf, RuleGroupLeader, RuleStaff, RuleService:
+			default:
+				return fmt.Errorf("method %s: unknown rule %q", method, rule)
+			}
+		}
+	}
+	return nil
+}
+
+// GroupLookup returns the group code of a user, for the group leader rule.
+type GroupLookup func(ctx context.Context, userUUID string) (string, error)
+
+type Authorizer struct {
+	policy Policy
+	groups GroupLookup
+}
+
+func NewAuthorizer(policy Policy, groups GroupLookup) *Authorizer {
+	return &Authorizer{policy: policy, groups: groups}
+}
+
+// Authorize reports whether identity may call fullMethod with req. Denials
+// wrap ErrPermissionDenied.
+func (a *Authorizer) Authorize(ctx context.Context, identity Identity, fullMethod string, req any) error {
+	method := path.Base(fullMethod)
+	rules, ok := a.policy[method]
+	if !ok {
+		return fmt.Errorf("%w: %s is not allowed by the policy", ErrPermissionDenied, method)
+	}
+	target := targetUser(req)
+
+	// Role checks first, group lookups cost two queries.
+	for _, rule := range rules {
+		switch rule {
+		case RuleStaff, RuleService:
+			if identity.HasRole(string(rule)) {
+				return nil
+			}
+		case RuleSelf:
+			if sameUser(identity.Subject, target) {
+				return nil
+			}
+		}
+	}
+	for _, rule := range rules {
+		if rule == RuleGroupLeader && identity.HasRole(string(RuleGroupLeader)) && target != "" {
+			ok, err := a.sameGroup(ctx, identity.Subject, target)
+			if err != nil {
+				return err
+			}
+			if ok {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s cannot call %s for user %s", ErrPermissionDenied, identity.Subject, method, target)
+}
+
+func (a *Authorizer) sameGroup(ctx context.Context, leader, target string) (bool, error) {
+	leaderGroup, err := a.groups(ctx, leader)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up group of %s: %w", leader, err)
+	}
+	targetGroup, err := a.groups(ctx, target)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up group of %s: %w", target, err)
+	}
+	return leaderGroup != "" && leaderGroup == targetGroup, nil
+}
+
+// sameUser compares two user UUIDs regardless of letter case.
+func sameUser(a, b string) bool {
+	ua, err := uuid.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := uuid.Parse(b)
+	return err == nil && ua == ub
+}
+
+// targetUser returns the user a request acts on.
+func targetUser(req any) string {
+	switch r := req.(type) {
+	case interface{ GetUserUuid() string }:
+		return r.GetUserUuid()
+	case interface{ GetUuid() string }:
+		return r.GetUuid()
+	}
+	return ""
+}
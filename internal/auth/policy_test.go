@@ -0,0 +1,124 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"labgrab/user_service/api/proto"
+	"labgrab/user_service/internal/auth"
+)
+
+const (
+	student  = "11111111-1111-4111-8111-111111111111"
+	leader   = "22222222-2222-4222-8222-222222222222"
+	outsider = "33333333-3333-4333-8333-333333333333"
+)
+
+var groups = map[string]string{
+	student:  "ИУ-7-1",
+	leader:   "ИУ-7-1",
+	outsider: "ИУ-7-2",
+}
+
+func lookupGroup(ctx context.Context, userUUID string) (string, error) {
+	return groups[userUUID], nil
+}
+
+func TestAuthorize(t *testing.T) {
+	authorizer := auth.NewAuthorizer(auth.DefaultPolicy(), lookupGroup)
+
+	var (
+		self        = auth.Identity{Subject: student}
+		groupLeader = auth.Identity{Subject: leader, Roles: []string{"group_leader"}}
+		otherLeader = auth.Identity{Subject: outsider, Roles: []string{"group_leader"}}
+		staff       = auth.Identity{Subject: "staff-1", Roles: []string{"staff"}}
+		svc         = auth.Identity{Subject: "lab-service", Roles: []string{"service"}}
+	)
+	getDetails := &proto.GetUserDetailsRequest{UserUuid: student}
+	updateEmail := &proto.UpdateUserEmailRequest{UserUuid: student, Email: "a@b.c"}
+	deleteUser := &proto.DeleteUserRequest{Uuid: student}
+
+	tests := []struct {
+		name     string
+		identity auth.Identity
+		method   string
+		req      any
+		allowed  bool
+	}{
+		{"self reads own details", self, "GetUserDetails", getDetails, true},
+		{"self edits own contacts", self, "UpdateUserEmail", updateEmail, true},
+		{"self creates own user", self, "CreateUser", &proto.CreateUserRequest{Uuid: student}, true},
+		{"self in another letter case", auth.Identity{Subject: "0b7f9c1e-4c1a-4d6e-9f6b-2a8d3c5e7f10"}, "GetUserDetails",
+			&proto.GetUserDetailsRequest{UserUuid: "0B7F9C1E-4C1A-4D6E-9F6B-2A8D3C5E7F10"}, true},
+		{"self without a target", auth.Identity{}, "GetUserDetails", &proto.GetUserDetailsRequest{}, false},
+		{"self cannot delete", self, "DeleteUser", deleteUser, false},
+		{"other student cannot read", auth.Identity{Subject: outsider}, "GetUserDetails", getDetails, false},
+		{"other student cannot edit", auth.Identity{Subject: outsider}, "UpdateUserEmail", updateEmail, false},
+		{"leader reads group member", groupLeader, "GetUserDetails", getDetails, true},
+		{"leader cannot edit group member", groupLeader, "UpdateUserEmail", updateEmail, false},
+		{"leader cannot move to another group", groupLeader, "UpdateUserGroupCode",
+			&proto.UpdateUserGroupCodeRequest{UserUuid: leader, GroupCode: "ИУ-7-2"}, false},
+		{"leader cannot create details in another group", groupLeader, "CreateUserDetails",
+			&proto.CreateUserDetailsRequest{UserUuid: leader, GroupCode: "ИУ-7-2"}, false},
+		{"staff sets group codes", staff, "UpdateUserGroupCode",
+			&proto.UpdateUserGroupCodeRequest{UserUuid: student, GroupCode: "ИУ-7-2"}, true},
+		{"leader of another group cannot read", otherLeader, "GetUserDetails", getDetails, false},
+		{"staff edits anyone", staff, "UpdateUserEmail", updateEmail, true},
+		{"staff deletes", staff, "DeleteUser", deleteUser, true},
+		{"service reads", svc, "GetUserContacts", &proto.GetUserContactsRequest{UserUuid: student}, true},
+		{"service cannot edit", svc, "UpdateUserEmail", updateEmail, false},
+		{"unknown method", staff, "ListUsers", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorizer.Authorize(context.Background(), tt.identity, "/proto.UserService/"+tt.method, tt.req)
+			if tt.allowed && err != nil {
+				t.Errorf("Authorize: %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, auth.ErrPermissionDenied) {
+				t.Errorf("Authorize = %v, want ErrPermissionDenied", err)
+			}
+		})
+	}
+}
+
+func TestAuthorizeGroupLookupError(t *testing.T) {
+	lookupErr := errors.New("database is down")
+	authorizer := auth.NewAuthorizer(auth.DefaultPolicy(), func(ctx context.Context, userUUID string) (string, error) {
+		return "", lookupErr
+	})
+
+	err := authorizer.Authorize(context.Background(), auth.Identity{Subject: leader, Roles: []string{"group_leader"}},
+		"/proto.UserService/GetUserDetails", &proto.GetUserDetailsRequest{UserUuid: student})
+	if !errors.Is(err, lookupErr) || errors.Is(err, auth.ErrPermissionDenied) {
+		t.Errorf("Authorize = %v, want the lookup error", err)
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"valid", `{"GetUserDetails": ["self", "staff"], "DeleteUser": []}`, false},
+		{"unknown method", `{"ListUsers": ["staff"]}`, true},
+		{"unknown rule", `{"GetUserDetails": ["admin"]}`, true},
+		{"not json", `GetUserDetails: [self]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "policy.json")
+			if err := os.WriteFile(file, []byte(tt.data), 0o600); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			_, err := auth.LoadPolicy(file)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
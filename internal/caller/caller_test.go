@@ -0,0 +1,42 @@
+package caller_test
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"labgrab/user_service/internal/caller"
+)
+
+func TestFromContext(t *testing.T) {
+	r := httptest.NewRequest("GET", "/v1/users", nil)
+	r.RemoteAddr = "203.0.113.7:51234"
+	forwarded := caller.Forward(context.Background(), r)
+
+	loopback := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 40000}})
+	spoofed := metadata.Pairs("x-gateway-token", "guess", "x-forwarded-client-addr", "198.51.100.1")
+	injected := metadata.Join(metadata.Pairs("x-forwarded-client-addr", "198.51.100.1"), forwarded)
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want string
+	}{
+		{"direct", nil, "127.0.0.1"},
+		{"gateway", forwarded, "203.0.113.7"},
+		{"spoofed", spoofed, "127.0.0.1"},
+		{"injected next to the gateway's", injected, "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(loopback, tt.md)
+			if got := caller.FromContext(ctx).Addr; got != tt.want {
+				t.Errorf("Addr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
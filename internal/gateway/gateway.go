@@ -0,0 +1,90 @@
+// Package gateway serves the UserService as HTTP/JSON, transcoded by
+// grpc-gateway from the google.api.http annotations in user.proto.
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"labgrab/user_service/api/proto"
+	"labgrab/user_service/internal/caller"
+)
+
+type Gateway struct {
+	conn    *grpc.ClientConn
+	handler http.Handler
+}
+
+// New returns a Gateway forwarding requests to the gRPC server at endpoint.
+// Requests go through the gRPC server rather than straight to the service,
+// so they pass the same interceptors as native gRPC calls.
+func New(ctx context.Context, endpoint string, opts ...grpc.DialOption) (*Gateway, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}, opts...)
+	conn, err := grpc.NewClient(endpoint, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", endpoint, err)
+	}
+
+	gwmux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			// Field names match user.proto and the OpenAPI document.
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithIncomingHeaderMatcher(headerMatcher),
+		// The gRPC server only sees the loopback connection, so the HTTP
+		// client is passed on for rate limiting and audit logs.
+		runtime.WithMetadata(caller.Forward),
+	)
+	if err := proto.RegisterUserServiceHandler(ctx, gwmux, conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to register gateway handler: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(proto.OpenAPI)
+	})
+	mux.Handle("/", gwmux)
+
+	return &Gateway{conn: conn, handler: mux}, nil
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.handler.ServeHTTP(w, r)
+}
+
+// Close closes the connection to the gRPC server. Call it after the HTTP
+// server has shut down.
+func (g *Gateway) Close() error {
+	return g.conn.Close()
+}
+
+// headerMatcher passes the headers the interceptors read under their gRPC
+// names; the default matcher would prefix them with "grpcgateway-". Headers
+// naming the metadata the gateway forwards about the client are dropped, so
+// the client cannot set them itself.
+func headerMatcher(key string) (string, bool) {
+	switch textproto.CanonicalMIMEHeaderKey(key) {
+	case "Authorization", "Accept-Language":
+		return strings.ToLower(key), true
+	}
+	name, ok := runtime.DefaultHeaderMatcher(key)
+	if !ok || caller.Reserved(name) {
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,139 @@
+package gateway_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"labgrab/user_service/api/proto"
+	"labgrab/user_service/internal/caller"
+	"labgrab/user_service/internal/gateway"
+	"labgrab/user_service/internal/repository"
+	"labgrab/user_service/internal/service"
+	"labgrab/user_service/internal/testserver"
+)
+
+const testUUID = "0b7f9c1e-4c1a-4d6e-9f6b-2a8d3c5e7f10"
+
+func newGateway(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	dialer := testserver.Serve(t, testserver.New(t))
+	gw, err := gateway.New(context.Background(), testserver.Target, dialer)
+	if err != nil {
+		t.Fatalf("gateway.New: %v", err)
+	}
+	t.Cleanup(func() { gw.Close() })
+
+	srv := httptest.NewServer(gw)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func do(t *testing.T, srv *httptest.Server, method, path, body string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+func TestRoutes(t *testing.T) {
+	srv := newGateway(t)
+
+	steps := []struct {
+		method, path, body string
+		wantCode           int
+		wantBody           string
+	}{
+		{"POST", "/v1/users", `{"uuid":"` + testUUID + `"}`, http.StatusOK, testUUID},
+		{"POST", "/v1/users", `{"uuid":"` + testUUID + `"}`, http.StatusConflict, "already exists"},
+		{"GET", "/v1/users/" + testUUID + "/details", "", http.StatusNotFound, "not found"},
+		{"POST", "/v1/users/" + testUUID + "/details",
+			`{"name":"Иван","surname":"Иванов","group_code":"ИУ-7-1"}`, http.StatusOK, `"group_code":"ИУ-7-1"`},
+		{"PATCH", "/v1/users/" + testUUID + "/details/name", `{"name":"Пётр"}`, http.StatusOK, `"name":"Пётр"`},
+		{"PATCH", "/v1/users/" + testUUID + "/details/group_code", `{"group_code":"bad"}`, http.StatusBadRequest, "group_code"},
+		{"GET", "/v1/users/" + testUUID + "/details", "", http.StatusOK, `"user_uuid":"` + testUUID + `"`},
+		{"POST", "/v1/users/" + testUUID + "/contacts", `{"phone_number":"+79990001122"}`, http.StatusOK, "+79990001122"},
+		{"PATCH", "/v1/users/" + testUUID + "/contacts/telegram_id", `{"telegram_id":"42"}`, http.StatusOK, `"telegram_id":"42"`},
+		{"GET", "/v1/users/" + testUUID + "/contacts", "", http.StatusOK, `"telegram_id":"42"`},
+		{"DELETE", "/v1/users/" + testUUID, "", http.StatusOK, "{}"},
+		{"GET", "/v1/users/" + testUUID + "/contacts", "", http.StatusNotFound, "not found"},
+	}
+	for _, step := range steps {
+		code, body := do(t, srv, step.method, step.path, step.body)
+		if code != step.wantCode || !strings.Contains(body, step.wantBody) {
+			t.Errorf("%s %s = %d %s, want %d containing %q", step.method, step.path, code, body, step.wantCode, step.wantBody)
+		}
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	srv := newGateway(t)
+
+	code, body := do(t, srv, "GET", "/openapi.json", "")
+	if code != http.StatusOK {
+		t.Fatalf("GET /openapi.json = %d", code)
+	}
+
+	var doc struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("invalid OpenAPI document: %v", err)
+	}
+	if _, ok := doc.Paths["/v1/users/{user_uuid}/details"]["get"]; !ok {
+		t.Errorf("OpenAPI document has no GET /v1/users/{user_uuid}/details")
+	}
+}
+
+func TestForwardedCallerCannotBeForged(t *testing.T) {
+	var got caller.Caller
+	s := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		got = caller.FromContext(ctx)
+		return handler(ctx, req)
+	}))
+	proto.RegisterUserServiceServer(s, &service.Service{Repo: repository.NewMemory()})
+	gw, err := gateway.New(context.Background(), testserver.Target, testserver.Serve(t, s))
+	if err != nil {
+		t.Fatalf("gateway.New: %v", err)
+	}
+	t.Cleanup(func() { gw.Close() })
+	srv := httptest.NewServer(gw)
+	t.Cleanup(srv.Close)
+
+	req, err := http.NewRequest("GET", srv.URL+"/v1/users/"+testUUID+"/details", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Grpc-Metadata-X-Forwarded-Client-Addr", "1.2.3.4")
+	req.Header.Set("Grpc-Metadata-X-Forwarded-Client-San", "spiffe://trusted-admin")
+	req.Header.Set("Grpc-Metadata-X-Gateway-Token", "guess")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+
+	if got != (caller.Caller{Addr: "127.0.0.1"}) {
+		t.Errorf("caller = %+v, want the HTTP client at 127.0.0.1 without a certificate", got)
+	}
+}
@@ -0,0 +1,91 @@
+// Package health reports the serving status of the process through
+// grpc.health.v1, based on whether the database answers pings.
+package health
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type Checker struct {
+	Server *grpchealth.Server
+
+	pinger   Pinger
+	logger   *zap.Logger
+	interval time.Duration
+	services []string
+	status   healthpb.HealthCheckResponse_ServingStatus
+}
+
+// NewChecker returns a Checker reporting on services and on the overall
+// server status (the empty service name). With a nil pinger the services are
+// always SERVING.
+func NewChecker(pinger Pinger, logger *zap.Logger, interval time.Duration, services ...string) *Checker {
+	c := &Checker{
+		Server:   grpchealth.NewServer(),
+		pinger:   pinger,
+		logger:   logger,
+		interval: interval,
+		services: append([]string{""}, services...),
+		status:   healthpb.HealthCheckResponse_UNKNOWN,
+	}
+	// Nothing has been checked yet, so the server must not look ready.
+	for _, service := range c.services {
+		c.Server.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	return c
+}
+
+// Run checks the database every interval until ctx is done.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check pings the database once and updates the status of all services.
+// Only changes of the status are logged.
+func (c *Checker) Check(ctx context.Context) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if c.pinger != nil {
+		ctx, cancel := context.WithTimeout(ctx, c.interval)
+		err := c.pinger.Ping(ctx)
+		cancel()
+		if err != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+			if c.status != status {
+				c.logger.Error("Database ping failed, not serving", zap.Error(err))
+			}
+		}
+	}
+	if status == healthpb.HealthCheckResponse_SERVING && c.status != status {
+		c.logger.Info("Serving")
+	}
+
+	c.status = status
+	for _, service := range c.services {
+		c.Server.SetServingStatus(service, status)
+	}
+}
+
+// Shutdown reports NOT_SERVING for every service and ignores later checks,
+// so load balancers stop sending traffic before the server drains.
+func (c *Checker) Shutdown() {
+	c.Server.Shutdown()
+}
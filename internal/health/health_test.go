@@ -0,0 +1,76 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"labgrab/user_service/internal/health"
+)
+
+type fakePinger struct {
+	err error
+}
+
+func (p *fakePinger) Ping(ctx context.Context) error {
+	return p.err
+}
+
+const service = "user.UserService"
+
+func statusOf(t *testing.T, c *health.Checker, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+
+	resp, err := c.Server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatalf("Check(%q): %v", service, err)
+	}
+	return resp.Status
+}
+
+func assertStatus(t *testing.T, c *health.Checker, want healthpb.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+
+	for _, name := range []string{"", service} {
+		if got := statusOf(t, c, name); got != want {
+			t.Errorf("status of %q = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCheckerFollowsPing(t *testing.T) {
+	pinger := &fakePinger{}
+	c := health.NewChecker(pinger, zap.NewNop(), time.Second, service)
+	assertStatus(t, c, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	c.Check(context.Background())
+	assertStatus(t, c, healthpb.HealthCheckResponse_SERVING)
+
+	pinger.err = errors.New("connection refused")
+	c.Check(context.Background())
+	assertStatus(t, c, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	pinger.err = nil
+	c.Check(context.Background())
+	assertStatus(t, c, healthpb.HealthCheckResponse_SERVING)
+}
+
+func TestCheckerWithoutDatabase(t *testing.T) {
+	c := health.NewChecker(nil, zap.NewNop(), time.Second, service)
+	c.Check(context.Background())
+	assertStatus(t, c, healthpb.HealthCheckResponse_SERVING)
+}
+
+func TestShutdownOverridesChecks(t *testing.T) {
+	c := health.NewChecker(&fakePinger{}, zap.NewNop(), time.Second, service)
+	c.Check(context.Background())
+	c.Shutdown()
+	assertStatus(t, c, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	c.Check(context.Background())
+	assertStatus(t, c, healthpb.HealthCheckResponse_NOT_SERVING)
+}
@@ -0,0 +1,81 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"labgrab/user_service/internal/auth"
+	"labgrab/user_service/internal/caller"
+)
+
+// UnaryAuth rejects calls without a valid bearer token in the authorization
+// metadata and stores the caller's identity in the context. Methods whose
+// full name starts with one of the public prefixes are let through.
+func UnaryAuth(verifier *auth.Verifier, public ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, verifier, info.FullMethod, public)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuth is the streaming counterpart of UnaryAuth.
+func StreamAuth(verifier *auth.Verifier, public ...string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), verifier, info.FullMethod, public)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, verifier *auth.Verifier, method string, public []string) (context.Context, error) {
+	if isPublic(method, public) {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(grpccodes.Unauthenticated, "missing authorization metadata")
+	}
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return nil, status.Error(grpccodes.Unauthenticated, "authorization must be a bearer token")
+	}
+
+	identity, err := verifier.Verify(ctx, strings.TrimSpace(token))
+	if err != nil {
+		return nil, status.Errorf(grpccodes.Unauthenticated, "%v", err)
+	}
+	identity.ClientSAN = caller.FromContext(ctx).ClientSAN
+	return auth.NewContext(ctx, identity), nil
+}
+
+// isPublic reports whether method starts with one of the public prefixes.
+func isPublic(method string, public []string) bool {
+	for _, prefix := range public {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// serverStream overrides the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,102 @@
+package interceptor_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"labgrab/user_service/internal/auth"
+	"labgrab/user_service/internal/interceptor"
+)
+
+// newAuth returns an auth interceptor trusting a fresh key and a token
+// signed with it for subject.
+func newAuth(t *testing.T, subject string) (grpc.UnaryServerInterceptor, string) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"OKP","crv":"Ed25519","alg":"EdDSA","kid":"k","x":%q}]}`,
+		base64.RawURLEncoding.EncodeToString(pub))
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, []byte(jwks), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	verifier, err := auth.NewVerifier(ctx, auth.Options{JWKS: path, RefreshInterval: time.Hour, Logger: zap.NewNop()})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{
+		"sub":   subject,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"staff"},
+	})
+	token.Header["kid"] = "k"
+	signed, err := token.SignedString(priv)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	return interceptor.UnaryAuth(verifier, "/grpc.health.v1.Health/"), signed
+}
+
+func TestUnaryAuth(t *testing.T) {
+	authenticate, token := newAuth(t, "student-1")
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		wantCode      grpccodes.Code
+		wantSubject   string
+	}{
+		{"valid token", "/proto.UserService/GetUserDetails", "Bearer " + token, grpccodes.OK, "student-1"},
+		{"lowercase scheme", "/proto.UserService/GetUserDetails", "bearer " + token, grpccodes.OK, "student-1"},
+		{"missing", "/proto.UserService/GetUserDetails", "", grpccodes.Unauthenticated, ""},
+		{"basic auth", "/proto.UserService/GetUserDetails", "Basic dXNlcjpwYXNz", grpccodes.Unauthenticated, ""},
+		{"tampered", "/proto.UserService/GetUserDetails", "Bearer " + token + "x", grpccodes.Unauthenticated, ""},
+		{"public method", "/grpc.health.v1.Health/Check", "", grpccodes.OK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.authorization))
+			}
+
+			var subject string
+			_, err := authenticate(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method},
+				func(ctx context.Context, req any) (any, error) {
+					if identity, ok := auth.FromContext(ctx); ok {
+						subject = identity.Subject
+					}
+					return nil, nil
+				})
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("code = %v, want %v (err: %v)", got, tt.wantCode, err)
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", subject, tt.wantSubject)
+			}
+		})
+	}
+}
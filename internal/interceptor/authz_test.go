@@ -0,0 +1,76 @@
+package interceptor_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"labgrab/user_service/api/proto"
+	"labgrab/user_service/internal/auth"
+	"labgrab/user_service/internal/interceptor"
+)
+
+func TestUnaryAuthorize(t *testing.T) {
+	const owner = "0b7f9c1e-4c1a-4d6e-9f6b-2a8d3c5e7f10"
+
+	authorizer := auth.NewAuthorizer(auth.DefaultPolicy(), func(ctx context.Context, userUUID string) (string, error) {
+		if userUUID == "broken" {
+			return "", errors.New("database is down")
+		}
+		return "", nil
+	})
+	authorize := interceptor.UnaryAuthorize(authorizer, zap.NewNop(), "/grpc.health.v1.Health/")
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		method   string
+		req      any
+		want     grpccodes.Code
+	}{
+		{"owner", &auth.Identity{Subject: owner}, "/proto.UserService/GetUserDetails", &proto.GetUserDetailsRequest{UserUuid: owner}, grpccodes.OK},
+		{"other user", &auth.Identity{Subject: "someone"}, "/proto.UserService/GetUserDetails", &proto.GetUserDetailsRequest{UserUuid: owner}, grpccodes.PermissionDenied},
+		{"no identity", nil, "/proto.UserService/GetUserDetails", &proto.GetUserDetailsRequest{UserUuid: owner}, grpccodes.Unauthenticated},
+		{"public method", nil, "/grpc.health.v1.Health/Check", nil, grpccodes.OK},
+		{"lookup failure", &auth.Identity{Subject: "someone", Roles: []string{"group_leader"}}, "/proto.UserService/GetUserDetails", &proto.GetUserDetailsRequest{UserUuid: "broken"}, grpccodes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.identity != nil {
+				ctx = auth.NewContext(ctx, *tt.identity)
+			}
+			_, err := authorize(ctx, tt.req, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("code = %v, want %v (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestUnaryAuthorizeHidesLookupErrors(t *testing.T) {
+	authorizer := auth.NewAuthorizer(auth.DefaultPolicy(), func(ctx context.Context, userUUID string) (string, error) {
+		return "", errors.New("password authentication failed for user postgres")
+	})
+	core, logs := observer.New(zapcore.DebugLevel)
+	authorize := interceptor.UnaryAuthorize(authorizer, zap.New(core))
+
+	ctx := auth.NewContext(context.Background(), auth.Identity{Subject: "someone", Roles: []string{"group_leader"}})
+	_, err := authorize(ctx, &proto.GetUserDetailsRequest{UserUuid: "0b7f9c1e-4c1a-4d6e-9f6b-2a8d3c5e7f10"},
+		&grpc.UnaryServerInfo{FullMethod: "/proto.UserService/GetUserDetails"},
+		func(ctx context.Context, req any) (any, error) { return "ok", nil })
+	if msg := status.Convert(err).Message(); msg != "internal error" {
+		t.Errorf("message = %q, want %q", msg, "internal error")
+	}
+	if n := logs.FilterMessage("Failed to authorize request").Len(); n != 1 {
+		t.Errorf("logged %d lookup failures, want 1", n)
+	}
+}
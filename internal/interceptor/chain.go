@@ -0,0 +1,27 @@
+package interceptor
+
+import (
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// UnaryCommon returns the interceptors every server chain starts with,
+// outermost first: error translation, span attributes, logging and panic
+// recovery. Handlers behind them only contain business logic.
+func UnaryCommon(log *zap.Logger) []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		UnaryErrors(),
+		UnaryTracing(),
+		UnaryLogging(log),
+		UnaryRecovery(log),
+	}
+}
+
+// StreamCommon is the streaming counterpart of UnaryCommon.
+func StreamCommon(log *zap.Logger) []grpc.StreamServerInterceptor {
+	return []grpc.StreamServerInterceptor{
+		StreamErrors(),
+		StreamLogging(log),
+		StreamRecovery(log),
+	}
+}
@@ -0,0 +1,107 @@
+package interceptor_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"labgrab/user_service/api/proto"
+	"labgrab/user_service/internal/interceptor"
+	"labgrab/user_service/internal/repository"
+)
+
+// callChain runs handler behind interceptors the way grpc.ChainUnaryInterceptor
+// does.
+func callChain(interceptors []grpc.UnaryServerInterceptor, req any, handler grpc.UnaryHandler) (any, error) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto.UserService/GetUserDetails"}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		next, ic := handler, interceptors[i]
+		handler = func(ctx context.Context, req any) (any, error) {
+			return ic(ctx, req, info, next)
+		}
+	}
+	return handler(context.Background(), req)
+}
+
+func TestUnaryCommon(t *testing.T) {
+	req := &proto.GetUserDetailsRequest{UserUuid: "0b7f9c1e-4c1a-4d6e-9f6b-2a8d3c5e7f10"}
+
+	tests := []struct {
+		name      string
+		handler   grpc.UnaryHandler
+		want      grpccodes.Code
+		wantLevel zapcore.Level
+	}{
+		{"success", func(ctx context.Context, req any) (any, error) {
+			return &proto.GetUserDetailsResponse{}, nil
+		}, grpccodes.OK, zapcore.DebugLevel},
+		{"not found", func(ctx context.Context, req any) (any, error) {
+			return nil, fmt.Errorf("failed to get user details: %w", repository.ErrNotFound)
+		}, grpccodes.NotFound, zapcore.WarnLevel},
+		{"already exists", func(ctx context.Context, req any) (any, error) {
+			return nil, fmt.Errorf("failed to create user: %w", repository.ErrAlreadyExists)
+		}, grpccodes.AlreadyExists, zapcore.WarnLevel},
+		{"invalid", func(ctx context.Context, req any) (any, error) {
+			return nil, fmt.Errorf("failed to update user name: %w", repository.ErrInvalid)
+		}, grpccodes.InvalidArgument, zapcore.WarnLevel},
+		{"status", func(ctx context.Context, req any) (any, error) {
+			return nil, status.Error(grpccodes.PermissionDenied, "no")
+		}, grpccodes.PermissionDenied, zapcore.WarnLevel},
+		{"database failure", func(ctx context.Context, req any) (any, error) {
+			return nil, errors.New("connection refused")
+		}, grpccodes.Internal, zapcore.ErrorLevel},
+		{"panic", func(ctx context.Context, req any) (any, error) {
+			var details *proto.UserDetails
+			return details.Name, nil
+		}, grpccodes.Internal, zapcore.ErrorLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			_, err := callChain(interceptor.UnaryCommon(zap.New(core)), req, tt.handler)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("code = %v, want %v (%v)", got, tt.want, err)
+			}
+
+			handled := logs.FilterMessage("Request handled").All()
+			if len(handled) != 1 {
+				t.Fatalf("logged %d calls, want 1", len(handled))
+			}
+			entry := handled[0]
+			if entry.Level != tt.wantLevel {
+				t.Errorf("level = %v, want %v", entry.Level, tt.wantLevel)
+			}
+			fields := entry.ContextMap()
+			if fields["method"] != "GetUserDetails" || fields["user_uuid"] != req.UserUuid || fields["code"] != tt.want.String() {
+				t.Errorf("fields = %v", fields)
+			}
+		})
+	}
+}
+
+func TestUnaryCommonHidesInternalErrors(t *testing.T) {
+	_, err := callChain(interceptor.UnaryCommon(zap.NewNop()), nil, func(ctx context.Context, req any) (any, error) {
+		return nil, errors.New("password authentication failed for user postgres")
+	})
+	if msg := status.Convert(err).Message(); msg != "internal error" {
+		t.Errorf("message = %q, want %q", msg, "internal error")
+	}
+}
+
+func TestUnaryCommonHidesDatabaseErrors(t *testing.T) {
+	dbErr := errors.New(`ERROR: duplicate key value violates unique constraint "users_pk" (SQLSTATE 23505)`)
+	_, err := callChain(interceptor.UnaryCommon(zap.NewNop()), nil, func(ctx context.Context, req any) (any, error) {
+		return nil, fmt.Errorf("failed to create user: %w", errors.Join(repository.ErrAlreadyExists, dbErr))
+	})
+	if msg := status.Convert(err).Message(); msg != "already exists" {
+		t.Errorf("message = %q, want %q", msg, "already exists")
+	}
+}
@@ -0,0 +1,72 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"labgrab/user_service/internal/repository"
+)
+
+// UnaryErrors turns the errors handlers return into gRPC statuses, so that
+// handlers can return repository errors as they are. It runs first in the
+// chain, after the tracing and logging interceptors have seen the original
+// error.
+func UnaryErrors() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, toStatus(err).Err()
+		}
+		return resp, nil
+	}
+}
+
+// StreamErrors is the streaming counterpart of UnaryErrors.
+func StreamErrors() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return toStatus(err).Err()
+		}
+		return nil
+	}
+}
+
+// toStatus maps err to the status the client sees. Statuses pass through
+// unchanged. Other errors get a fixed message per kind: they may wrap
+// database errors, which are only logged.
+func toStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(grpccodes.OK, "")
+	}
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return status.New(grpccodes.NotFound, repository.ErrNotFound.Error())
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return status.New(grpccodes.AlreadyExists, repository.ErrAlreadyExists.Error())
+	case errors.Is(err, repository.ErrInvalid):
+		return status.New(grpccodes.InvalidArgument, repository.ErrInvalid.Error())
+	case errors.Is(err, context.Canceled):
+		return status.New(grpccodes.Canceled, context.Canceled.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(grpccodes.DeadlineExceeded, context.DeadlineExceeded.Error())
+	}
+	return status.New(grpccodes.Internal, "internal error")
+}
+
+// serverFault reports whether code means the server failed rather than the
+// client sending a request that cannot succeed.
+func serverFault(code grpccodes.Code) bool {
+	switch code {
+	case grpccodes.Unknown, grpccodes.DeadlineExceeded, grpccodes.Unimplemented,
+		grpccodes.Internal, grpccodes.Unavailable, grpccodes.DataLoss:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package interceptor
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+
+	"labgrab/user_service/internal/caller"
+	"labgrab/user_service/pkg/logger"
+)
+
+// UnaryLogging logs every call with its method, user, client certificate,
+// status code and duration: successful calls at debug level, client errors
+// as warnings and server faults as errors.
+func UnaryLogging(log *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		fields := []zap.Field{zap.String("method", path.Base(info.FullMethod))}
+		if id := requestUser(req); id != "" {
+			fields = append(fields, zap.String("user_uuid", id))
+		}
+		logCall(ctx, log, start, err, fields...)
+		return resp, err
+	}
+}
+
+// StreamLogging is the streaming counterpart of UnaryLogging.
+func StreamLogging(log *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), log, start, err, zap.String("method", path.Base(info.FullMethod)))
+		return err
+	}
+}
+
+func logCall(ctx context.Context, log *zap.Logger, start time.Time, err error, fields ...zap.Field) {
+	code := toStatus(err).Code()
+	level := zapcore.DebugLevel
+	switch {
+	case serverFault(code):
+		level = zapcore.ErrorLevel
+	case code != grpccodes.OK:
+		level = zapcore.WarnLevel
+	}
+
+	log = logger.WithTraceContext(ctx, log)
+	if ce := log.Check(level, "Request handled"); ce != nil {
+		if san := caller.FromContext(ctx).ClientSAN; san != "" {
+			fields = append(fields, zap.String("client_san", san))
+		}
+		fields = append(fields, zap.String("code", code.String()), zap.Duration("duration", time.Since(start)))
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+		ce.Write(fields...)
+	}
+}
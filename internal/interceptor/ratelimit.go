@@ -0,0 +1,77 @@
+package interceptor
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"labgrab/user_service/internal/auth"
+	"labgrab/user_service/internal/caller"
+	"labgrab/user_service/internal/ratelimit"
+)
+
+// UnaryRateLimit rejects calls over their client's limit with
+// ResourceExhausted and a RetryInfo detail saying when to retry. Clients are
+// told apart by auth subject, then client certificate, then address, so it
+// belongs after UnaryAuth. For gateway calls the certificate and address
+// are those the gateway forwarded. Methods whose full name starts with one
+// of the public prefixes are not limited.
+func UnaryRateLimit(limiter *ratelimit.Limiter, public ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := rateLimit(ctx, limiter, info.FullMethod, public); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimit is the streaming counterpart of UnaryRateLimit. A stream
+// takes one token when it opens.
+func StreamRateLimit(limiter *ratelimit.Limiter, public ...string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := rateLimit(ss.Context(), limiter, info.FullMethod, public); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func rateLimit(ctx context.Context, limiter *ratelimit.Limiter, method string, public []string) error {
+	if isPublic(method, public) {
+		return nil
+	}
+
+	ok, delay := limiter.Allow(path.Base(method), clientKey(ctx), time.Now())
+	if ok {
+		return nil
+	}
+	st, err := status.New(grpccodes.ResourceExhausted, "rate limit exceeded").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	if err != nil {
+		return status.Error(grpccodes.ResourceExhausted, "rate limit exceeded")
+	}
+	return st.Err()
+}
+
+// clientKey only uses what the client cannot choose: caller.FromContext
+// ignores forwarded values the gateway did not set, and falls back to the
+// peer, so made-up headers do not get a client a fresh bucket.
+func clientKey(ctx context.Context) string {
+	if identity, ok := auth.FromContext(ctx); ok {
+		return "subject:" + identity.Subject
+	}
+	c := caller.FromContext(ctx)
+	if c.ClientSAN != "" {
+		return "san:" + c.ClientSAN
+	}
+	if c.Addr != "" {
+		return "ip:" + c.Addr
+	}
+	return "unknown"
+}
@@ -0,0 +1,83 @@
+package interceptor_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"labgrab/user_service/internal/auth"
+	"labgrab/user_service/internal/caller"
+	"labgrab/user_service/internal/interceptor"
+	"labgrab/user_service/internal/ratelimit"
+)
+
+func TestUnaryRateLimit(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Limits{"*": {Rate: 1, Burst: 1}})
+	limit := interceptor.UnaryRateLimit(limiter, "/grpc.health.v1.Health/")
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+	call := func(ctx context.Context, method string) error {
+		_, err := limit(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	fromIP := func(ip string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}})
+	}
+	bot := fromIP("10.0.0.1")
+	if err := call(bot, "/proto.UserService/GetUserContacts"); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	err := call(bot, "/proto.UserService/GetUserContacts")
+	if status.Code(err) != grpccodes.ResourceExhausted {
+		t.Fatalf("second call: code = %v, want ResourceExhausted", status.Code(err))
+	}
+	var retry *errdetails.RetryInfo
+	for _, d := range status.Convert(err).Details() {
+		if r, ok := d.(*errdetails.RetryInfo); ok {
+			retry = r
+		}
+	}
+	if retry == nil || retry.GetRetryDelay().AsDuration() <= 0 {
+		t.Errorf("details = %v, want a RetryInfo with a positive delay", status.Convert(err).Details())
+	}
+
+	if err := call(fromIP("10.0.0.2"), "/proto.UserService/GetUserContacts"); err != nil {
+		t.Errorf("call from another address: %v", err)
+	}
+	if err := call(bot, "/grpc.health.v1.Health/Check"); err != nil {
+		t.Errorf("public method: %v", err)
+	}
+
+	// Made-up forwarding metadata does not get a fresh bucket.
+	for _, addr := range []string{"198.51.100.1", "198.51.100.2"} {
+		forged := metadata.Pairs("x-gateway-token", "guess", "x-forwarded-client-addr", addr)
+		err := call(metadata.NewIncomingContext(bot, forged), "/proto.UserService/GetUserContacts")
+		if status.Code(err) != grpccodes.ResourceExhausted {
+			t.Errorf("call forged as %s: code = %v, want ResourceExhausted", addr, status.Code(err))
+		}
+	}
+
+	// Behind the gateway all calls come from the loopback address, the
+	// forwarded address or the subject tells the callers apart.
+	gateway := fromIP("127.0.0.1")
+	for _, addr := range []string{"203.0.113.1:5000", "203.0.113.2:5000"} {
+		md := caller.Forward(gateway, &http.Request{RemoteAddr: addr})
+		if err := call(metadata.NewIncomingContext(gateway, md), "/proto.UserService/GetUserContacts"); err != nil {
+			t.Errorf("gateway call from %s: %v", addr, err)
+		}
+	}
+	for _, subject := range []string{"alice", "bob"} {
+		ctx := auth.NewContext(bot, auth.Identity{Subject: subject})
+		if err := call(ctx, "/proto.UserService/GetUserContacts"); err != nil {
+			t.Errorf("call as %s: %v", subject, err)
+		}
+	}
+}
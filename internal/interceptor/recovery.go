@@ -0,0 +1,71 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"labgrab/user_service/pkg/logger"
+)
+
+// panics is created from the global meter provider, which forwards to the
+// provider installed at startup.
+var panics, _ = otel.Meter("labgrab/user_service/internal/interceptor").Int64Counter(
+	"rpc.server.panics",
+	metric.WithDescription("Number of handler panics recovered by the server."),
+	metric.WithUnit("{panic}"),
+)
+
+// UnaryRecovery turns a panic in the rest of the chain into an Internal
+// error instead of crashing the process. The panic is logged with its stack
+// and trace IDs, recorded on the span and counted in rpc.server.panics.
+func UnaryRecovery(log *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = recovered(ctx, log, info.FullMethod, p)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecovery is the streaming counterpart of UnaryRecovery.
+func StreamRecovery(log *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = recovered(ss.Context(), log, info.FullMethod, p)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// recovered must be called from the deferred function, so that the stacks
+// it captures still contain the frames that panicked.
+func recovered(ctx context.Context, log *zap.Logger, fullMethod string, p any) error {
+	method := path.Base(fullMethod)
+	logger.WithTraceContext(ctx, log).Error("Handler panicked",
+		zap.String("method", method),
+		zap.Any("panic", p),
+		zap.Stack("stack"),
+	)
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(fmt.Errorf("panic: %v", p), trace.WithStackTrace(true))
+	span.SetStatus(codes.Error, "panic")
+
+	panics.Add(ctx, 1, metric.WithAttributes(attribute.String("rpc.method", method)))
+	return status.Error(grpccodes.Internal, "internal error")
+}
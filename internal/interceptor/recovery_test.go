@@ -0,0 +1,97 @@
+package interceptor_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"labgrab/user_service/internal/interceptor"
+)
+
+// metrics reads the global meter provider. Instruments created before the
+// first SetMeterProvider stay bound to that provider, so it is installed
+// once for the whole test binary.
+var metrics = sync.OnceValue(func() *sdkmetric.ManualReader {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	return reader
+})
+
+func panicCount(t *testing.T, method string) int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := metrics().Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "rpc.server.panics" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				if v, _ := dp.Attributes.Value("rpc.method"); v.AsString() == method {
+					total += dp.Value
+				}
+			}
+		}
+	}
+	return total
+}
+
+func TestUnaryRecovery(t *testing.T) {
+	before := panicCount(t, "GetUserDetails")
+	spans := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer("test").Start(context.Background(), "call")
+
+	core, logs := observer.New(zapcore.ErrorLevel)
+	recovery := interceptor.UnaryRecovery(zap.New(core))
+	_, err := recovery(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/proto.UserService/GetUserDetails"},
+		func(ctx context.Context, req any) (any, error) {
+			var m map[string]int
+			m["boom"]++
+			return nil, nil
+		})
+	span.End()
+
+	if status.Code(err) != grpccodes.Internal {
+		t.Fatalf("code = %v, want Internal", status.Code(err))
+	}
+
+	entries := logs.FilterMessage("Handler panicked").All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d panics, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["trace_id"] != span.SpanContext().TraceID().String() {
+		t.Errorf("trace_id = %v, want %v", fields["trace_id"], span.SpanContext().TraceID())
+	}
+	if stack, _ := fields["stack"].(string); !strings.Contains(stack, "TestUnaryRecovery") {
+		t.Errorf("stack does not contain the panicking function:\n%s", stack)
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 1 || len(ended[0].Events()) != 1 || ended[0].Events()[0].Name != "exception" {
+		t.Fatalf("span events = %v, want one exception", ended[0].Events())
+	}
+	if ended[0].Status().Description != "panic" {
+		t.Errorf("span status = %v, want an error with description panic", ended[0].Status())
+	}
+
+	if got := panicCount(t, "GetUserDetails") - before; got != 1 {
+		t.Errorf("rpc.server.panics{rpc.method=GetUserDetails} grew by %d, want 1", got)
+	}
+}
@@ -0,0 +1,48 @@
+package interceptor
+
+import (
+	"context"
+	"path"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// UnaryTracing annotates the server span started by the otelgrpc stats
+// handler with the method and the user the request is about, and marks it
+// failed when the handler returns an error.
+func UnaryTracing() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		span := trace.SpanFromContext(ctx)
+		span.SetAttributes(attribute.String("grpc.method", path.Base(info.FullMethod)))
+		if id := requestUser(req); id != "" {
+			span.SetAttributes(attribute.String("user.uuid", id))
+		}
+		if r, ok := req.(interface{ GetGroupCode() string }); ok && r.GetGroupCode() != "" {
+			span.SetAttributes(attribute.String("user.group_code", r.GetGroupCode()))
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			st := toStatus(err)
+			span.SetStatus(codes.Error, st.Message())
+			if serverFault(st.Code()) {
+				span.RecordError(err)
+			}
+		}
+		return resp, err
+	}
+}
+
+// requestUser returns the UUID of the user a UserService request is about.
+func requestUser(req any) string {
+	switch r := req.(type) {
+	case interface{ GetUserUuid() string }:
+		return r.GetUserUuid()
+	case interface{ GetUuid() string }:
+		return r.GetUuid()
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"buf.build/go/protovalidate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const defaultLocale = "en-US"
+
+// fieldMessages holds human-readable descriptions keyed by the violated
+// field's name. Every field name in user.proto carries the same rule in all
+// messages, so the name is enough to pick the right text.
+var fieldMessages = map[string]map[string]string{
+	"name":       alphabeticMessages,
+	"surname":    alphabeticMessages,
+	"patronymic": alphabeticMessages,
+	"group_code": {
+		"en-US": "must look like ИТ-1-1: 2-3 letters and two 1-2 digit numbers separated by hyphens",
+		"ru-RU": "должен иметь вид ИТ-1-1: 2-3 буквы и два числа из 1-2 цифр через дефис",
+	},
+	"phone_number": {
+		"en-US": "must be in E.164 format, e.g. +79991234567",
+		"ru-RU": "должен быть в формате E.164, например +79991234567",
+	},
+	"email": {
+		"en-US": "must be a valid email address",
+		"ru-RU": "должен быть корректным адресом электронной почты",
+	},
+	"telegram_id": {
+		"en-US": "must be a positive number",
+		"ru-RU": "должен быть положительным числом",
+	},
+	"uuid":      uuidMessages,
+	"user_uuid": uuidMessages,
+}
+
+var alphabeticMessages = map[string]string{
+	"en-US": "must contain only letters, spaces, dots, underscores and hyphens",
+	"ru-RU": "может содержать только буквы, пробелы, точки, подчёркивания и дефисы",
+}
+
+var uuidMessages = map[string]string{
+	"en-US": "must be a valid UUID",
+	"ru-RU": "должен быть корректным UUID",
+}
+
+// UnaryValidator rejects requests that break the buf.validate rules declared
+// in the proto definitions before they reach the handler. All violations are
+// reported at once as google.rpc.BadRequest details.
+func UnaryValidator(validator protovalidate.Validator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if msg, ok := req.(proto.Message); ok {
+			if err := validator.Validate(msg); err != nil {
+				return nil, validationStatus(ctx, err)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+func validationStatus(ctx context.Context, err error) error {
+	var valErr *protovalidate.ValidationError
+	if !errors.As(err, &valErr) {
+		return status.Errorf(grpccodes.Internal, "failed to validate request: %v", err)
+	}
+
+	locale := localeFromContext(ctx)
+	fields := make([]string, 0, len(valErr.Violations))
+	badRequest := &errdetails.BadRequest{}
+	for _, v := range valErr.Violations {
+		field := protovalidate.FieldPathString(v.Proto.GetField())
+		fields = append(fields, field)
+
+		description := v.Proto.GetMessage()
+		localized := description
+		if messages, ok := fieldMessages[fieldName(field)]; ok {
+			description = messages[defaultLocale]
+			localized = messages[locale]
+		}
+
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: field + " " + description,
+			Reason:      v.Proto.GetRuleId(),
+			LocalizedMessage: &errdetails.LocalizedMessage{
+				Locale:  locale,
+				Message: localized,
+			},
+		})
+	}
+
+	st := status.New(grpccodes.InvalidArgument, "invalid fields: "+strings.Join(fields, ", "))
+	detailed, detailsErr := st.WithDetails(badRequest)
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func fieldName(path string) string {
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		path = path[i+1:]
+	}
+	if i := strings.IndexByte(path, '['); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
+
+// localeFromContext picks the first supported language from the
+// accept-language metadata, falling back to English.
+func localeFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return defaultLocale
+	}
+	for _, header := range md.Get("accept-language") {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(strings.SplitN(tag, ";", 2)[0])
+			switch strings.ToLower(strings.SplitN(tag, "-", 2)[0]) {
+			case "ru":
+				return "ru-RU"
+			case "en":
+				return "en-US"
+			}
+		}
+	}
+	return defaultLocale
+}
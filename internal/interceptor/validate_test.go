@@ -0,0 +1,137 @@
+package interceptor_test
+
+import (
+	"context"
+	"testing"
+
+	"buf.build/go/protovalidate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"labgrab/user_service/api/proto"
+	"labgrab/user_service/internal/interceptor"
+)
+
+func newValidator(t *testing.T) grpc.UnaryServerInterceptor {
+	t.Helper()
+
+	validator, err := protovalidate.New()
+	if err != nil {
+		t.Fatalf("protovalidate.New: %v", err)
+	}
+	return interceptor.UnaryValidator(validator)
+}
+
+func fieldViolations(t *testing.T, err error) []*errdetails.BadRequest_FieldViolation {
+	t.Helper()
+
+	st, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("expected gRPC status error, got %v", err)
+	}
+	if st.Code() != grpccodes.InvalidArgument {
+		t.Fatalf("code = %v, want %v", st.Code(), grpccodes.InvalidArgument)
+	}
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			return br.FieldViolations
+		}
+	}
+	t.Fatalf("status %v has no BadRequest details", st)
+	return nil
+}
+
+func TestUnaryValidatorReportsAllViolations(t *testing.T) {
+	validate := newValidator(t)
+	patronymic := "Петрович1"
+	called := false
+
+	_, err := validate(context.Background(), &proto.CreateUserDetailsRequest{
+		Name:       "Иван1",
+		Surname:    "Иванов",
+		Patronymic: &patronymic,
+		GroupCode:  "ИТ11",
+		UserUuid:   "not-a-uuid",
+	}, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+		called = true
+		return nil, nil
+	})
+
+	if called {
+		t.Fatal("handler called for invalid request")
+	}
+	got := fieldViolations(t, err)
+	want := []string{"name", "patronymic", "group_code", "user_uuid"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d violations, want %d: %v", len(got), len(want), got)
+	}
+	for i, field := range want {
+		if got[i].Field != field {
+			t.Errorf("violation[%d].Field = %q, want %q", i, got[i].Field, field)
+		}
+		if got[i].LocalizedMessage.GetMessage() == "" {
+			t.Errorf("violation[%d] has no localized message", i)
+		}
+	}
+}
+
+func TestUnaryValidatorPassesValidRequest(t *testing.T) {
+	validate := newValidator(t)
+	called := false
+
+	_, err := validate(context.Background(), &proto.UpdateUserNameRequest{
+		UserUuid: "e4b2a4a4-9a6e-4d0b-8f8e-6f1f3f0c2a11",
+		Name:     "Иван",
+	}, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+		called = true
+		return nil, nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !called {
+		t.Fatal("handler not called for valid request")
+	}
+}
+
+func TestUnaryValidatorLocale(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "no header", want: "en-US"},
+		{name: "russian", acceptLanguage: "ru-RU,ru;q=0.9", want: "ru-RU"},
+		{name: "english", acceptLanguage: "en-GB", want: "en-US"},
+		{name: "unsupported falls through", acceptLanguage: "de-DE, ru;q=0.5", want: "ru-RU"},
+		{name: "unsupported only", acceptLanguage: "de-DE", want: "en-US"},
+	}
+
+	validate := newValidator(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.acceptLanguage != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("accept-language", tt.acceptLanguage))
+			}
+
+			_, err := validate(ctx, &proto.UpdateUserNameRequest{
+				UserUuid: "e4b2a4a4-9a6e-4d0b-8f8e-6f1f3f0c2a11",
+			}, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+				return nil, nil
+			})
+
+			got := fieldViolations(t, err)
+			if len(got) != 1 {
+				t.Fatalf("got %d violations, want 1", len(got))
+			}
+			if got[0].LocalizedMessage.GetLocale() != tt.want {
+				t.Errorf("locale = %q, want %q", got[0].LocalizedMessage.GetLocale(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+// Package mtls serves TLS from certificate files that are reloaded when they
+// change, optionally verifying client certificates against a CA bundle.
+package mtls
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+type Options struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is a PEM bundle that client certificates are verified
+	// against. Client certificates are not requested when it is empty.
+	ClientCAFile string
+	// RequireClientCert rejects clients without a certificate. Otherwise a
+	// certificate is only verified when the client presents one.
+	RequireClientCert bool
+	ReloadInterval    time.Duration
+	Logger            *zap.Logger
+}
+
+// Reloader holds the certificate and client CAs loaded from Options. A
+// reload that fails leaves the previous ones in place.
+type Reloader struct {
+	opts Options
+	// loopback is the client certificate of LoopbackConfig. It is made up
+	// at startup and trusted next to the client CAs, so the server
+	// certificate need not be valid for client authentication.
+	loopback tls.Certificate
+
+	mu        sync.RWMutex
+	modTimes  []time.Time
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// New loads the files and checks them for changes every ReloadInterval
+// until ctx is done.
+func New(ctx context.Context, opts Options) (*Reloader, error) {
+	loopback, err := loopbackCertificate()
+	if err != nil {
+		return nil, err
+	}
+	r := &Reloader{opts: opts, loopback: loopback}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ticker := time.NewTicker(opts.ReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.reload(); err != nil {
+					opts.Logger.Error("Failed to reload TLS certificates", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return r, nil
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.opts.CertFile, r.opts.KeyFile}
+	if r.opts.ClientCAFile != "" {
+		files = append(files, r.opts.ClientCAFile)
+	}
+	return files
+}
+
+func (r *Reloader) reload() error {
+	files := r.files()
+	modTimes := make([]time.Time, len(files))
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		modTimes[i] = info.ModTime()
+	}
+	r.mu.RLock()
+	unchanged := r.modTimes != nil && slices.EqualFunc(modTimes, r.modTimes, time.Time.Equal)
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	// The certificate and key are replaced by separate writes, so a pair
+	// that does not match yet is reported and retried on the next tick.
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if r.opts.ClientCAFile != "" {
+		data, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA bundle: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificates in client CA bundle %s", r.opts.ClientCAFile)
+		}
+		clientCAs.AddCert(r.loopback.Leaf)
+	}
+
+	r.mu.Lock()
+	r.cert, r.clientCAs, r.modTimes = &cert, clientCAs, modTimes
+	r.mu.Unlock()
+	r.opts.Logger.Info("Loaded TLS certificate", zap.String("cert", r.opts.CertFile),
+		zap.String("client_ca", r.opts.ClientCAFile))
+	return nil
+}
+
+func (r *Reloader) certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+// ServerConfig returns the server side configuration for the gRPC server
+// and the HTTP listeners. Every handshake uses the certificate and client
+// CAs loaded at that moment.
+func (r *Reloader) ServerConfig() *tls.Config {
+	clientAuth := tls.VerifyClientCertIfGiven
+	if r.opts.RequireClientCert {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+				// The per-connection config replaces the one the server
+				// set up, so the protocols are offered here.
+				NextProtos: []string{"h2", "http/1.1"},
+			}
+			if r.clientCAs != nil {
+				cfg.ClientCAs = r.clientCAs
+				cfg.ClientAuth = clientAuth
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// LoopbackConfig returns a client configuration for connecting to this
+// server from the same process, as the HTTP gateway does. The server is
+// trusted if it presents the loaded certificate, and the client presents a
+// certificate only this process has the key of.
+func (r *Reloader) LoopbackConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// The certificate is pinned below instead of verified against a
+		// CA, it need not be valid for localhost.
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 || !bytes.Equal(cs.PeerCertificates[0].Raw, r.certificate().Certificate[0]) {
+				return errors.New("server did not present the loaded certificate")
+			}
+			return nil
+		},
+		Certificates: []tls.Certificate{r.loopback},
+	}
+}
+
+// loopbackCertificate returns a self-signed client certificate with a new
+// key, which the server trusts as its own root.
+func loopbackCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate loopback key: %w", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "user_service loopback"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create loopback certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create loopback certificate: %w", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
+
+// ClientSAN returns the first subject alternative name of the verified
+// client certificate of the call in ctx: a URI (such as a SPIFFE ID), else
+// a DNS name, else an email address.
+func ClientSAN(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return "", false
+	}
+	return StateSAN(info.State)
+}
+
+// StateSAN is ClientSAN for the state of a TLS connection, such as that of
+// an HTTP request.
+func StateSAN(state tls.ConnectionState) (string, bool) {
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	cert := state.VerifiedChains[0][0]
+	switch {
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String(), true
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0], true
+	case len(cert.EmailAddresses) > 0:
+		return cert.EmailAddresses[0], true
+	}
+	return "", false
+}
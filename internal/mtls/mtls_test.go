@@ -0,0 +1,335 @@
+package mtls_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"labgrab/user_service/internal/mtls"
+)
+
+type issuer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newCA(t *testing.T) issuer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return issuer{cert: cert, key: key}
+}
+
+// issue returns a PEM certificate and key for a leaf signed by the CA, valid
+// for both server and client authentication.
+func (ca issuer) issue(t *testing.T, serial int64, dnsName string, uris ...string) (certPEM, keyPEM []byte) {
+	t.Helper()
+	return ca.issueFor(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}, serial, dnsName, uris...)
+}
+
+func (ca issuer) issueFor(t *testing.T, usage []x509.ExtKeyUsage, serial int64, dnsName string, uris ...string) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  usage,
+	}
+	for _, u := range uris {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			t.Fatalf("url.Parse: %v", err)
+		}
+		tmpl.URIs = append(tmpl.URIs, parsed)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func (ca issuer) pem() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+}
+
+type server struct {
+	addr     string
+	reloader *mtls.Reloader
+	dir      string
+	sans     chan string
+}
+
+func startServer(t *testing.T, ca issuer, require bool) *server {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, 2, "server.test")
+	return startServerWith(t, ca, require, certPEM, keyPEM)
+}
+
+func startServerWith(t *testing.T, ca issuer, require bool, certPEM, keyPEM []byte) *server {
+	t.Helper()
+	dir := t.TempDir()
+	now := time.Now()
+	writeFile(t, filepath.Join(dir, "cert.pem"), certPEM, now)
+	writeFile(t, filepath.Join(dir, "key.pem"), keyPEM, now)
+	writeFile(t, filepath.Join(dir, "ca.pem"), ca.pem(), now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	reloader, err := mtls.New(ctx, mtls.Options{
+		CertFile:          filepath.Join(dir, "cert.pem"),
+		KeyFile:           filepath.Join(dir, "key.pem"),
+		ClientCAFile:      filepath.Join(dir, "ca.pem"),
+		RequireClientCert: require,
+		ReloadInterval:    10 * time.Millisecond,
+		Logger:            zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	srv := &server{reloader: reloader, dir: dir, sans: make(chan string, 10)}
+	s := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(reloader.ServerConfig())),
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			san, _ := mtls.ClientSAN(ctx)
+			srv.sans <- san
+			return handler(ctx, req)
+		}),
+	)
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	srv.addr = lis.Addr().String()
+	return srv
+}
+
+func (s *server) check(t *testing.T, cfg *tls.Config) error {
+	t.Helper()
+	conn, err := grpc.NewClient(s.addr, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	return err
+}
+
+func clientConfig(t *testing.T, ca issuer, certPEM, keyPEM []byte) *tls.Config {
+	t.Helper()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	cfg := &tls.Config{RootCAs: roots, ServerName: "server.test"}
+	if certPEM != nil {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			t.Fatalf("X509KeyPair: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg
+}
+
+func TestClientCertificate(t *testing.T) {
+	ca := newCA(t)
+	certPEM, keyPEM := ca.issue(t, 3, "client.test", "spiffe://labgrab/lab-service")
+	untrustedCert, untrustedKey := newCA(t).issue(t, 4, "client.test")
+
+	tests := []struct {
+		name    string
+		require bool
+		client  *tls.Config
+		wantErr bool
+		wantSAN string
+	}{
+		{"required and presented", true, clientConfig(t, ca, certPEM, keyPEM), false, "spiffe://labgrab/lab-service"},
+		{"required and missing", true, clientConfig(t, ca, nil, nil), true, ""},
+		{"optional and missing", false, clientConfig(t, ca, nil, nil), false, ""},
+		{"untrusted issuer", false, clientConfig(t, ca, untrustedCert, untrustedKey), true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := startServer(t, ca, tt.require)
+			err := srv.check(t, tt.client)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if san := <-srv.sans; san != tt.wantSAN {
+				t.Errorf("ClientSAN = %q, want %q", san, tt.wantSAN)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	ca := newCA(t)
+	srv := startServer(t, ca, false)
+
+	certPEM, keyPEM := ca.issue(t, 10, "server.test")
+	later := time.Now().Add(time.Minute)
+	writeFile(t, filepath.Join(srv.dir, "cert.pem"), certPEM, later)
+	writeFile(t, filepath.Join(srv.dir, "key.pem"), keyPEM, later)
+
+	cfg := clientConfig(t, ca, nil, nil)
+	var serial *big.Int
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		serial = cs.PeerCertificates[0].SerialNumber
+		return nil
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if err := srv.check(t, cfg); err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+		<-srv.sans
+		if serial.Int64() == 10 {
+			return
+		}
+	}
+	t.Errorf("server still presents certificate %v after the files changed", serial)
+}
+
+func TestReloadKeepsCertificateOnError(t *testing.T) {
+	ca := newCA(t)
+	srv := startServer(t, ca, false)
+
+	writeFile(t, filepath.Join(srv.dir, "cert.pem"), []byte("not a certificate"), time.Now().Add(time.Minute))
+	time.Sleep(50 * time.Millisecond)
+
+	if err := srv.check(t, clientConfig(t, ca, nil, nil)); err != nil {
+		t.Errorf("Check after a broken reload: %v", err)
+	}
+}
+
+func TestLoopbackConfig(t *testing.T) {
+	ca := newCA(t)
+	// An ordinary server certificate, not valid for client authentication.
+	certPEM, keyPEM := ca.issueFor(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, 2, "server.test")
+	for _, require := range []bool{true, false} {
+		srv := startServerWith(t, ca, require, certPEM, keyPEM)
+		if err := srv.check(t, srv.reloader.LoopbackConfig()); err != nil {
+			t.Fatalf("Check with require = %v: %v", require, err)
+		}
+		if san := <-srv.sans; san != "" {
+			t.Errorf("ClientSAN = %q, want none", san)
+		}
+	}
+	srv := startServer(t, ca, true)
+
+	// A different server with a certificate from the same CA is not trusted.
+	other := startServer(t, ca, false)
+	if err := other.check(t, srv.reloader.LoopbackConfig()); err == nil {
+		t.Error("loopback connection to another server succeeded")
+	}
+}
+
+func TestHTTPServer(t *testing.T) {
+	ca := newCA(t)
+	srv := startServer(t, ca, true)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	httpServer := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, r.Proto)
+		}),
+		TLSConfig: srv.reloader.ServerConfig(),
+	}
+	go httpServer.ServeTLS(lis, "", "")
+	t.Cleanup(func() { httpServer.Close() })
+
+	get := func(cfg *tls.Config) (string, error) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg, ForceAttemptHTTP2: true}}
+		defer client.CloseIdleConnections()
+		resp, err := client.Get("https://" + lis.Addr().String())
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	if _, err := get(clientConfig(t, ca, nil, nil)); err == nil {
+		t.Error("request without a client certificate succeeded")
+	}
+	certPEM, keyPEM := ca.issue(t, 3, "client.test")
+	proto, err := get(clientConfig(t, ca, certPEM, keyPEM))
+	if err != nil {
+		t.Fatalf("request with a client certificate: %v", err)
+	}
+	if proto != "HTTP/2.0" {
+		t.Errorf("protocol = %q, want HTTP/2.0", proto)
+	}
+}
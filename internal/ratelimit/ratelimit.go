@@ -0,0 +1,149 @@
+// Package ratelimit keeps a token bucket per client and method, and one per
+// client across all methods, so a single misbehaving client cannot exhaust
+// the database pool.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"labgrab/user_service/api/proto"
+)
+
+// Default is the method name of the limit applied to methods without a
+// limit of their own.
+const Default = "*"
+
+// Quota is the method name of the limit on all calls of a client together,
+// whatever the method. It applies on top of the method limits.
+const Quota = "all"
+
+type Limit struct {
+	// Rate is the number of calls per second refilled into the bucket.
+	Rate  float64
+	Burst int
+}
+
+// Limits maps UserService method names, Default or Quota to their limits.
+// Methods without a limit are only held to the quota.
+type Limits map[string]Limit
+
+// ParseLimits parses a comma separated list of method=rate[:burst], for
+// example "all=50:100,*=20:40,GetUserContacts=5". The burst defaults to the rate
+// rounded up.
+func ParseLimits(s string) (Limits, error) {
+	methods := map[string]bool{Default: true, Quota: true}
+	for _, m := range proto.UserService_ServiceDesc.Methods {
+		methods[m.MethodName] = true
+	}
+
+	limits := Limits{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		method, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q: want method=rate[:burst]", entry)
+		}
+		method = strings.TrimSpace(method)
+		if !methods[method] {
+			return nil, fmt.Errorf("%q: unknown method %q", entry, method)
+		}
+
+		rateStr, burstStr, hasBurst := strings.Cut(value, ":")
+		r, err := strconv.ParseFloat(strings.TrimSpace(rateStr), 64)
+		if err != nil || r <= 0 || math.IsInf(r, 0) {
+			return nil, fmt.Errorf("%q: rate must be a positive number", entry)
+		}
+		burst := int(math.Ceil(r))
+		if hasBurst {
+			burst, err = strconv.Atoi(strings.TrimSpace(burstStr))
+			if err != nil || burst <= 0 {
+				return nil, fmt.Errorf("%q: burst must be a positive integer", entry)
+			}
+		}
+		limits[method] = Limit{Rate: r, Burst: burst}
+	}
+	return limits, nil
+}
+
+// sweepInterval is how often buckets that have refilled completely are
+// dropped. A full bucket is the same as a new one, so dropping it changes
+// nothing for the client.
+const sweepInterval = 10 * time.Minute
+
+type Limiter struct {
+	limits Limits
+
+	mu        sync.Mutex
+	buckets   map[string]*rate.Limiter
+	lastSweep time.Time
+}
+
+func New(limits Limits) *Limiter {
+	return &Limiter{limits: limits, buckets: map[string]*rate.Limiter{}}
+}
+
+// Allow takes a token from the bucket of client for method and from its
+// quota. When either is empty it returns false and how long until both
+// have a token available.
+func (l *Limiter) Allow(method, client string, now time.Time) (bool, time.Duration) {
+	limit, ok := l.limits[method]
+	if !ok {
+		limit, ok = l.limits[Default]
+	}
+	quota, hasQuota := l.limits[Quota]
+	if !ok && !hasQuota {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) > sweepInterval {
+		for key, b := range l.buckets {
+			if b.TokensAt(now) >= float64(b.Burst()) {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	var reservations []*rate.Reservation
+	if ok {
+		reservations = append(reservations, l.reserve(method+"\x00"+client, limit, now))
+	}
+	if hasQuota {
+		reservations = append(reservations, l.reserve(Quota+"\x00"+client, quota, now))
+	}
+
+	var delay time.Duration
+	for _, r := range reservations {
+		delay = max(delay, r.DelayFrom(now))
+	}
+	if delay > 0 {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+		return false, delay
+	}
+	return true, 0
+}
+
+// reserve takes a token from the bucket under key, creating it with limit
+// if needed. l.mu must be held.
+func (l *Limiter) reserve(key string, limit Limit, now time.Time) *rate.Reservation {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)
+		l.buckets[key] = b
+	}
+	return b.ReserveN(now, 1)
+}
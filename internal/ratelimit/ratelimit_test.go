@@ -0,0 +1,118 @@
+package ratelimit_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"labgrab/user_service/internal/ratelimit"
+)
+
+func TestParseLimits(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ratelimit.Limits
+		wantErr bool
+	}{
+		{"", ratelimit.Limits{}, false},
+		{"*=20:40, GetUserContacts=0.5", ratelimit.Limits{
+			"*":               {Rate: 20, Burst: 40},
+			"GetUserContacts": {Rate: 0.5, Burst: 1},
+		}, false},
+		{"all=50:100", ratelimit.Limits{"all": {Rate: 50, Burst: 100}}, false},
+		{"GetUserContacts", nil, true},
+		{"ListUsers=5", nil, true},
+		{"GetUserContacts=0", nil, true},
+		{"GetUserContacts=fast", nil, true},
+		{"GetUserContacts=5:0", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ratelimit.ParseLimits(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLimits(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseLimits(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAllow(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Limits{
+		"*":               {Rate: 100, Burst: 100},
+		"GetUserContacts": {Rate: 1, Burst: 2},
+	})
+	now := time.Now()
+
+	for i := range 2 {
+		if ok, _ := limiter.Allow("GetUserContacts", "bot", now); !ok {
+			t.Fatalf("call %d within the burst was limited", i+1)
+		}
+	}
+	ok, delay := limiter.Allow("GetUserContacts", "bot", now)
+	if ok {
+		t.Fatal("call over the burst was allowed")
+	}
+	if delay <= 0 || delay > time.Second {
+		t.Errorf("delay = %v, want within (0, 1s]", delay)
+	}
+
+	if ok, _ := limiter.Allow("GetUserContacts", "student", now); !ok {
+		t.Error("another client was limited")
+	}
+	if ok, _ := limiter.Allow("GetUserDetails", "bot", now); !ok {
+		t.Error("another method was limited")
+	}
+	if ok, _ := limiter.Allow("GetUserContacts", "bot", now.Add(delay)); !ok {
+		t.Error("call after the returned delay was limited")
+	}
+}
+
+func TestAllowWithoutLimit(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Limits{"GetUserContacts": {Rate: 1, Burst: 1}})
+	now := time.Now()
+	for range 10 {
+		if ok, _ := limiter.Allow("GetUserDetails", "bot", now); !ok {
+			t.Fatal("method without a limit was limited")
+		}
+	}
+}
+
+func TestAllowQuota(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Limits{
+		"all":             {Rate: 1, Burst: 3},
+		"GetUserContacts": {Rate: 1, Burst: 2},
+	})
+	now := time.Now()
+
+	for _, method := range []string{"GetUserContacts", "GetUserDetails", "UpdateUserEmail"} {
+		if ok, _ := limiter.Allow(method, "bot", now); !ok {
+			t.Fatalf("%s within the quota was limited", method)
+		}
+	}
+	ok, delay := limiter.Allow("DeleteUser", "bot", now)
+	if ok {
+		t.Fatal("call over the quota was allowed")
+	}
+	if delay <= 0 || delay > time.Second {
+		t.Errorf("delay = %v, want within (0, 1s]", delay)
+	}
+	if ok, _ := limiter.Allow("GetUserDetails", "student", now); !ok {
+		t.Error("another client was limited")
+	}
+	if ok, _ := limiter.Allow("GetUserContacts", "bot", now.Add(delay)); !ok {
+		t.Error("call after the returned delay was limited")
+	}
+}
+
+func TestSlowBucketOutlivesSweep(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Limits{"*": {Rate: 0.001, Burst: 2}})
+	now := time.Now()
+	for range 2 {
+		limiter.Allow("GetUserDetails", "bot", now)
+	}
+	if ok, _ := limiter.Allow("GetUserDetails", "bot", now.Add(11*time.Minute)); ok {
+		t.Error("call was allowed after a sweep although the bucket had not refilled")
+	}
+}
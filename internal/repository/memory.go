@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"labgrab/user_service/internal/validation"
+)
+
+// Memory is a UserRepository kept in process memory. It mirrors the
+// behaviour of the SQL schema: primary and foreign keys, cascading deletes
+// and the CHECK constraints generated from internal/validation.
+type Memory struct {
+	mu       sync.RWMutex
+	users    map[uuid.UUID]struct{}
+	details  map[uuid.UUID]Details
+	contacts map[uuid.UUID]Contacts
+}
+
+var _ UserRepository = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{
+		users:    make(map[uuid.UUID]struct{}),
+		details:  make(map[uuid.UUID]Details),
+		contacts: make(map[uuid.UUID]Contacts),
+	}
+}
+
+func (m *Memory) CreateUser(ctx context.Context, userUUID uuid.UUID) (uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userUUID]; ok {
+		return uuid.Nil, fmt.Errorf("%w: user %s", ErrAlreadyExists, userUUID)
+	}
+	m.users[userUUID] = struct{}{}
+	return userUUID, nil
+}
+
+func (m *Memory) DeleteUser(ctx context.Context, userUUID uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.users[userUUID]
+	delete(m.users, userUUID)
+	delete(m.details, userUUID)
+	delete(m.contacts, userUUID)
+	return ok, nil
+}
+
+func (m *Memory) GetUserDetails(ctx context.Context, userUUID uuid.UUID) (Details, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	details, ok := m.details[userUUID]
+	if !ok {
+		return Details{}, fmt.Errorf("%w: details of user %s", ErrNotFound, userUUID)
+	}
+	return copyDetails(details), nil
+}
+
+func (m *Memory) CreateUserDetails(ctx context.Context, details Details) (Details, error) {
+	if err := checkDetails(details); err != nil {
+		return Details{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[details.UserUUID]; !ok {
+		return Details{}, fmt.Errorf("%w: user %s", ErrNotFound, details.UserUUID)
+	}
+	if _, ok := m.details[details.UserUUID]; ok {
+		return Details{}, fmt.Errorf("%w: details of user %s", ErrAlreadyExists, details.UserUUID)
+	}
+	m.details[details.UserUUID] = copyDetails(details)
+	return copyDetails(details), nil
+}
+
+func (m *Memory) UpdateUserName(ctx context.Context, userUUID uuid.UUID, name string) (Details, error) {
+	return m.updateDetails(userUUID, func(d *Details) { d.Name = name })
+}
+
+func (m *Memory) UpdateUserSurname(ctx context.Context, userUUID uuid.UUID, surname string) (Details, error) {
+	return m.updateDetails(userUUID, func(d *Details) { d.Surname = surname })
+}
+
+func (m *Memory) UpdateUserPatronymic(ctx context.Context, userUUID uuid.UUID, patronymic string) (Details, error) {
+	return m.updateDetails(userUUID, func(d *Details) { d.Patronymic = &patronymic })
+}
+
+func (m *Memory) UpdateUserGroupCode(ctx context.Context, userUUID uuid.UUID, groupCode string) (Details, error) {
+	return m.updateDetails(userUUID, func(d *Details) { d.GroupCode = groupCode })
+}
+
+func (m *Memory) GetUserContacts(ctx context.Context, userUUID uuid.UUID) (Contacts, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	contacts, ok := m.contacts[userUUID]
+	if !ok {
+		return Contacts{}, fmt.Errorf("%w: contacts of user %s", ErrNotFound, userUUID)
+	}
+	return copyContacts(contacts), nil
+}
+
+func (m *Memory) CreateUserContacts(ctx context.Context, contacts Contacts) (Contacts, error) {
+	if err := checkContacts(contacts); err != nil {
+		return Contacts{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[contacts.UserUUID]; !ok {
+		return Contacts{}, fmt.Errorf("%w: user %s", ErrNotFound, contacts.UserUUID)
+	}
+	if _, ok := m.contacts[contacts.UserUUID]; ok {
+		return Contacts{}, fmt.Errorf("%w: contacts of user %s", ErrAlreadyExists, contacts.UserUUID)
+	}
+	m.contacts[contacts.UserUUID] = copyContacts(contacts)
+	return copyContacts(contacts), nil
+}
+
+func (m *Memory) UpdateUserPhoneNumber(ctx context.Context, userUUID uuid.UUID, phoneNumber string) (Contacts, error) {
+	return m.updateContacts(userUUID, func(c *Contacts) { c.PhoneNumber = phoneNumber })
+}
+
+func (m *Memory) UpdateUserEmail(ctx context.Context, userUUID uuid.UUID, email string) (Contacts, error) {
+	return m.updateContacts(userUUID, func(c *Contacts) { c.Email = &email })
+}
+
+func (m *Memory) UpdateUserTelegramID(ctx context.Context, userUUID uuid.UUID, telegramID int64) (Contacts, error) {
+	return m.updateContacts(userUUID, func(c *Contacts) { c.TelegramID = &telegramID })
+}
+
+func (m *Memory) updateDetails(userUUID uuid.UUID, update func(*Details)) (Details, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	details, ok := m.details[userUUID]
+	if !ok {
+		return Details{}, fmt.Errorf("%w: details of user %s", ErrNotFound, userUUID)
+	}
+	details = copyDetails(details)
+	update(&details)
+	if err := checkDetails(details); err != nil {
+		return Details{}, err
+	}
+	m.details[userUUID] = details
+	return copyDetails(details), nil
+}
+
+func (m *Memory) updateContacts(userUUID uuid.UUID, update func(*Contacts)) (Contacts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	contacts, ok := m.contacts[userUUID]
+	if !ok {
+		return Contacts{}, fmt.Errorf("%w: contacts of user %s", ErrNotFound, userUUID)
+	}
+	contacts = copyContacts(contacts)
+	update(&contacts)
+	if err := checkContacts(contacts); err != nil {
+		return Contacts{}, err
+	}
+	m.contacts[userUUID] = contacts
+	return copyContacts(contacts), nil
+}
+
+func checkDetails(d Details) error {
+	columns := map[string]*string{
+		"name":       &d.Name,
+		"surname":    &d.Surname,
+		"patronymic": d.Patronymic,
+		"group_code": &d.GroupCode,
+	}
+	return checkColumns("users_details", columns)
+}
+
+func checkContacts(c Contacts) error {
+	columns := map[string]*string{
+		"phone_number": &c.PhoneNumber,
+	}
+	if err := checkColumns("users_contacts", columns); err != nil {
+		return err
+	}
+	if c.TelegramID != nil && !validation.ValidateTelegramID(*c.TelegramID) {
+		return fmt.Errorf("%w: users_contacts violates telegram_id_check", ErrInvalid)
+	}
+	return nil
+}
+
+// checkColumns applies the generated CHECK constraints of table. A nil value
+// stands for SQL NULL, which passes a CHECK like it does in Postgres.
+func checkColumns(table string, columns map[string]*string) error {
+	for _, c := range validation.Constraints {
+		if c.Table != table {
+			continue
+		}
+		value := columns[c.Column]
+		if value != nil && !c.Pattern.MatchString(*value) {
+			return fmt.Errorf("%w: %s violates %s", ErrInvalid, table, c.Name)
+		}
+	}
+	return nil
+}
+
+func copyDetails(d Details) Details {
+	if d.Patronymic != nil {
+		patronymic := *d.Patronymic
+		d.Patronymic = &patronymic
+	}
+	return d
+}
+
+func copyContacts(c Contacts) Contacts {
+	if c.Email != nil {
+		email := *c.Email
+		c.Email = &email
+	}
+	if c.TelegramID != nil {
+		telegramID := *c.TelegramID
+		c.TelegramID = &telegramID
+	}
+	return c
+}
@@ -0,0 +1,166 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"labgrab/user_service/internal/repository"
+)
+
+func newUser(t *testing.T, repo *repository.Memory) uuid.UUID {
+	t.Helper()
+
+	userUUID := uuid.New()
+	if _, err := repo.CreateUser(context.Background(), userUUID); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return userUUID
+}
+
+func validDetails(userUUID uuid.UUID) repository.Details {
+	return repository.Details{
+		UserUUID:  userUUID,
+		Name:      "Иван",
+		Surname:   "Иванов",
+		GroupCode: "ИТ-1-1",
+	}
+}
+
+func TestMemoryCreateUserTwice(t *testing.T) {
+	repo := repository.NewMemory()
+	userUUID := newUser(t, repo)
+
+	_, err := repo.CreateUser(context.Background(), userUUID)
+	if !errors.Is(err, repository.ErrAlreadyExists) {
+		t.Fatalf("err = %v, want ErrAlreadyExists", err)
+	}
+}
+
+func TestMemoryDetailsRequireUser(t *testing.T) {
+	repo := repository.NewMemory()
+
+	_, err := repo.CreateUserDetails(context.Background(), validDetails(uuid.New()))
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+
+	_, err = repo.CreateUserContacts(context.Background(), repository.Contacts{UserUUID: uuid.New(), PhoneNumber: "+79991234567"})
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryDetailsUnique(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	userUUID := newUser(t, repo)
+
+	if _, err := repo.CreateUserDetails(ctx, validDetails(userUUID)); err != nil {
+		t.Fatalf("CreateUserDetails: %v", err)
+	}
+	_, err := repo.CreateUserDetails(ctx, validDetails(userUUID))
+	if !errors.Is(err, repository.ErrAlreadyExists) {
+		t.Fatalf("err = %v, want ErrAlreadyExists", err)
+	}
+}
+
+func TestMemoryDeleteCascades(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	userUUID := newUser(t, repo)
+
+	if _, err := repo.CreateUserDetails(ctx, validDetails(userUUID)); err != nil {
+		t.Fatalf("CreateUserDetails: %v", err)
+	}
+	if _, err := repo.CreateUserContacts(ctx, repository.Contacts{UserUUID: userUUID, PhoneNumber: "+79991234567"}); err != nil {
+		t.Fatalf("CreateUserContacts: %v", err)
+	}
+	if deleted, err := repo.DeleteUser(ctx, userUUID); err != nil || !deleted {
+		t.Fatalf("DeleteUser = %v, %v, want true, nil", deleted, err)
+	}
+	if deleted, err := repo.DeleteUser(ctx, userUUID); err != nil || deleted {
+		t.Errorf("second DeleteUser = %v, %v, want false, nil", deleted, err)
+	}
+
+	if _, err := repo.GetUserDetails(ctx, userUUID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetUserDetails err = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.GetUserContacts(ctx, userUUID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetUserContacts err = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.CreateUser(ctx, userUUID); err != nil {
+		t.Errorf("CreateUser after delete: %v", err)
+	}
+}
+
+func TestMemoryCheckConstraints(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	userUUID := newUser(t, repo)
+
+	bad := validDetails(userUUID)
+	bad.GroupCode = "ИТ11"
+	if _, err := repo.CreateUserDetails(ctx, bad); !errors.Is(err, repository.ErrInvalid) {
+		t.Fatalf("CreateUserDetails err = %v, want ErrInvalid", err)
+	}
+
+	if _, err := repo.CreateUserDetails(ctx, validDetails(userUUID)); err != nil {
+		t.Fatalf("CreateUserDetails: %v", err)
+	}
+	if _, err := repo.UpdateUserName(ctx, userUUID, "Иван1"); !errors.Is(err, repository.ErrInvalid) {
+		t.Fatalf("UpdateUserName err = %v, want ErrInvalid", err)
+	}
+	details, err := repo.GetUserDetails(ctx, userUUID)
+	if err != nil {
+		t.Fatalf("GetUserDetails: %v", err)
+	}
+	if details.Name != "Иван" {
+		t.Errorf("name = %q after rejected update, want unchanged", details.Name)
+	}
+
+	contacts := repository.Contacts{UserUUID: userUUID, PhoneNumber: "+79991234567"}
+	if _, err := repo.CreateUserContacts(ctx, contacts); err != nil {
+		t.Fatalf("CreateUserContacts: %v", err)
+	}
+	if _, err := repo.UpdateUserTelegramID(ctx, userUUID, 0); !errors.Is(err, repository.ErrInvalid) {
+		t.Fatalf("UpdateUserTelegramID err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestMemoryUpdateMissing(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	userUUID := newUser(t, repo)
+
+	if _, err := repo.UpdateUserSurname(ctx, userUUID, "Петров"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("UpdateUserSurname err = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.UpdateUserEmail(ctx, userUUID, "ivanov@example.com"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("UpdateUserEmail err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	userUUID := newUser(t, repo)
+
+	patronymic := "Петрович"
+	details := validDetails(userUUID)
+	details.Patronymic = &patronymic
+	if _, err := repo.CreateUserDetails(ctx, details); err != nil {
+		t.Fatalf("CreateUserDetails: %v", err)
+	}
+	patronymic = "Changed"
+
+	got, err := repo.GetUserDetails(ctx, userUUID)
+	if err != nil {
+		t.Fatalf("GetUserDetails: %v", err)
+	}
+	if *got.Patronymic != "Петрович" {
+		t.Errorf("patronymic = %q, stored value was aliased", *got.Patronymic)
+	}
+}
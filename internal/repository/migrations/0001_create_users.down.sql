@@ -0,0 +1,5 @@
+drop table if exists public.users_contacts;
+
+drop table if exists public.users_details;
+
+drop table if exists public.users;
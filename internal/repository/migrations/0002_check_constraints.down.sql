@@ -0,0 +1,10 @@
+-- Code generated by internal/validation/gen from rules.json. DO NOT EDIT.
+
+alter table public.users_details
+    drop constraint if exists name_check,
+    drop constraint if exists surname_check,
+    drop constraint if exists patronymic_check,
+    drop constraint if exists group_code_check;
+
+alter table public.users_contacts
+    drop constraint if exists phone_number_check;
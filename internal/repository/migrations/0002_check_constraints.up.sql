@@ -0,0 +1,17 @@
+-- Code generated by internal/validation/gen from rules.json. DO NOT EDIT.
+-- Existing constraints are dropped first, so the same output can be
+-- written to a new migration whenever a rule changes.
+
+alter table public.users_details
+    drop constraint if exists name_check,
+    add constraint name_check check ((name ~ '^[\p{L}\_\-\. ]+$'::text)),
+    drop constraint if exists surname_check,
+    add constraint surname_check check ((surname ~ '^[\p{L}\_\-\. ]+$'::text)),
+    drop constraint if exists patronymic_check,
+    add constraint patronymic_check check ((patronymic ~ '^[\p{L}\_\-\. ]+$'::text)),
+    drop constraint if exists group_code_check,
+    add constraint group_code_check check ((group_code ~ '^\p{L}{2,3}\-[0-9]{1,2}\-[0-9]{1,2}$'::text));
+
+alter table public.users_contacts
+    drop constraint if exists phone_number_check,
+    add constraint phone_number_check check ((phone_number ~ '^\+[1-9]\d{1,14}$'::text));
@@ -0,0 +1,318 @@
+// Package migrations applies the versioned schema migrations embedded in the
+// binary. Each migration is a pair of NNNN_name.up.sql and NNNN_name.down.sql
+// files; applied versions and their checksums are recorded in the
+// schema_migrations table, and concurrent runs are serialized with a
+// Postgres advisory lock.
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed *.sql
+var embedded embed.FS
+
+// lockKey identifies the advisory lock held while migrating.
+const lockKey int64 = 0x75736572_73766300
+
+var fileNameRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+var ErrChecksumMismatch = errors.New("applied migration differs from embedded one")
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	// Modified is set when the applied checksum differs from the embedded one.
+	Modified bool
+}
+
+type applied struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// Load reads migrations from fsys, ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		m := fileNameRegexp.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", entry.Name(), err)
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has files named %q and %q", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		sum := sha256.Sum256([]byte(migration.Up))
+		migration.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// New returns a Migrator for the migrations embedded in the binary.
+func New(pool *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := Load(embedded)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Up applies all pending migrations and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		state, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(state); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := state[migration.Version]; ok {
+				continue
+			}
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx,
+					`insert into schema_migrations (version, name, checksum) values ($1, $2, $3)`,
+					migration.Version, migration.Name, migration.Checksum)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the most recently applied migration. It returns false when
+// there is nothing to roll back.
+func (m *Migrator) Down(ctx context.Context) (Migration, bool, error) {
+	var (
+		rolledBack Migration
+		found      bool
+	)
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		state, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(state); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := state[m.migrations[i].Version]; ok {
+				rolledBack, found = m.migrations[i], true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+		if rolledBack.Down == "" {
+			return fmt.Errorf("migration %d_%s has no down file", rolledBack.Version, rolledBack.Name)
+		}
+
+		err = pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, rolledBack.Down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `delete from schema_migrations where version = $1`, rolledBack.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to roll back migration %d_%s: %w", rolledBack.Version, rolledBack.Name, err)
+		}
+		return nil
+	})
+	return rolledBack, found, err
+}
+
+// Status reports every embedded migration and whether it has been applied.
+// It only reads: without a schema_migrations table all migrations are
+// pending, and the table is not created, so it works for read-only roles.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	var exists bool
+	err = conn.QueryRow(ctx, `select to_regclass('schema_migrations') is not null`).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up schema_migrations: %w", err)
+	}
+	state := map[int64]applied{}
+	if exists {
+		if state, err = loadApplied(ctx, conn.Conn()); err != nil {
+			return nil, err
+		}
+	}
+
+	var statuses []Status
+	for _, migration := range m.migrations {
+		s := Status{Migration: migration}
+		if a, ok := state[migration.Version]; ok {
+			appliedAt := a.appliedAt
+			s.AppliedAt = &appliedAt
+			s.Modified = a.checksum != migration.Checksum
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Pending reports whether any embedded migration has not been applied yet.
+func (m *Migrator) Pending(ctx context.Context) (bool, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Migrator) verify(state map[int64]applied) error {
+	known := map[int64]Migration{}
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+	for version, a := range state {
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("database has migration %d which this binary does not know", version)
+		}
+		if a.checksum != migration.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `select pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), `select pg_advisory_unlock($1)`, lockKey)
+
+	_, err = conn.Exec(ctx, `
+		create table if not exists schema_migrations
+		(
+			version    bigint      not null,
+			name       text        not null,
+			checksum   text        not null,
+			applied_at timestamptz not null default now(),
+			constraint schema_migrations_pk primary key (version)
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn.Conn())
+}
+
+func loadApplied(ctx context.Context, conn *pgx.Conn) (map[int64]applied, error) {
+	rows, err := conn.Query(ctx, `select version, checksum, applied_at from schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	state := map[int64]applied{}
+	for rows.Next() {
+		var (
+			version int64
+			a       applied
+		)
+		if err := rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		state[version] = a
+	}
+	return state, rows.Err()
+}
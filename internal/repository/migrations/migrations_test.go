@@ -0,0 +1,169 @@
+package migrations
+
+import (
+	"context"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Load(embedded)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d_%s: versions must be contiguous from 1", m.Version, m.Name)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []int64
+		wantErr bool
+	}{
+		{
+			name: "ordered by version",
+			files: fstest.MapFS{
+				"0010_b.up.sql":   {Data: []byte("select 2")},
+				"0002_a.up.sql":   {Data: []byte("select 1")},
+				"0002_a.down.sql": {Data: []byte("select 1")},
+			},
+			want: []int64{2, 10},
+		},
+		{
+			name:  "non sql files ignored",
+			files: fstest.MapFS{"0001_a.up.sql": {Data: []byte("select 1")}, "README.md": {}},
+			want:  []int64{1},
+		},
+		{
+			name:    "missing up file",
+			files:   fstest.MapFS{"0001_a.down.sql": {Data: []byte("select 1")}},
+			wantErr: true,
+		},
+		{
+			name:    "bad file name",
+			files:   fstest.MapFS{"create_users.sql": {Data: []byte("select 1")}},
+			wantErr: true,
+		},
+		{
+			name: "names differ",
+			files: fstest.MapFS{
+				"0001_a.up.sql":   {Data: []byte("select 1")},
+				"0001_b.down.sql": {Data: []byte("select 1")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.files)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d migrations, want %d", len(got), len(tt.want))
+			}
+			for i, version := range tt.want {
+				if got[i].Version != version {
+					t.Errorf("migration[%d].Version = %d, want %d", i, got[i].Version, version)
+				}
+			}
+		})
+	}
+}
+
+func TestChecksumTracksUpFile(t *testing.T) {
+	a, err := Load(fstest.MapFS{"0001_a.up.sql": {Data: []byte("select 1")}})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	b, err := Load(fstest.MapFS{"0001_a.up.sql": {Data: []byte("select 2")}})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if a[0].Checksum == b[0].Checksum {
+		t.Error("checksum did not change with the up file")
+	}
+}
+
+// TestMigratorRoundTrip runs only when TEST_DB_CONNECT points at a scratch
+// database; it rolls every migration back and applies it again.
+func TestMigratorRoundTrip(t *testing.T) {
+	dsn := os.Getenv("TEST_DB_CONNECT")
+	if dsn == "" {
+		t.Skip("TEST_DB_CONNECT not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("Unable to connect to database: %v", err)
+	}
+	defer pool.Close()
+
+	migrator, err := New(pool)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	for {
+		_, ok, err := migrator.Down(ctx)
+		if err != nil {
+			t.Fatalf("Down: %v", err)
+		}
+		if !ok {
+			break
+		}
+	}
+
+	// Status must not create the table it reads.
+	if _, err := pool.Exec(ctx, `drop table if exists schema_migrations`); err != nil {
+		t.Fatalf("drop schema_migrations: %v", err)
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil || !pending {
+		t.Fatalf("Pending without schema_migrations = %v, %v, want true, nil", pending, err)
+	}
+	var exists bool
+	if err := pool.QueryRow(ctx, `select to_regclass('schema_migrations') is not null`).Scan(&exists); err != nil || exists {
+		t.Errorf("schema_migrations exists = %v (%v) after Pending, want not created", exists, err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != len(migrator.migrations) {
+		t.Errorf("applied %d migrations, want %d", len(applied), len(migrator.migrations))
+	}
+	pending, err = migrator.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+	if pending {
+		t.Error("migrations still pending after Up")
+	}
+}
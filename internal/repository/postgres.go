@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"labgrab/user_service/internal/repository/sqlc"
+)
+
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+)
+
+// Postgres is the UserRepository backed by the sqlc queries.
+type Postgres struct {
+	queries *sqlc.Queries
+}
+
+var _ UserRepository = (*Postgres)(nil)
+
+func NewPostgres(db sqlc.DBTX) *Postgres {
+	return &Postgres{queries: sqlc.New(db)}
+}
+
+func (p *Postgres) CreateUser(ctx context.Context, userUUID uuid.UUID) (uuid.UUID, error) {
+	created, err := p.queries.CreateUser(ctx, userUUID)
+	return created, mapError(err)
+}
+
+func (p *Postgres) DeleteUser(ctx context.Context, userUUID uuid.UUID) (bool, error) {
+	deleted, err := p.queries.DeleteUser(ctx, userUUID)
+	return deleted > 0, mapError(err)
+}
+
+func (p *Postgres) GetUserDetails(ctx context.Context, userUUID uuid.UUID) (Details, error) {
+	details, err := p.queries.GetUserDetails(ctx, userUUID)
+	return detailsFromRow(details), mapError(err)
+}
+
+func (p *Postgres) CreateUserDetails(ctx context.Context, details Details) (Details, error) {
+	row, err := p.queries.CreateUserDetails(ctx, sqlc.CreateUserDetailsParams{
+		Name:       details.Name,
+		Surname:    details.Surname,
+		Patronymic: textFromPtr(details.Patronymic),
+		GroupCode:  details.GroupCode,
+		UserUuid:   details.UserUUID,
+	})
+	return detailsFromRow(row), mapError(err)
+}
+
+func (p *Postgres) UpdateUserName(ctx context.Context, userUUID uuid.UUID, name string) (Details, error) {
+	row, err := p.queries.UpdateUserName(ctx, sqlc.UpdateUserNameParams{
+		UserUuid: userUUID,
+		Name:     name,
+	})
+	return detailsFromRow(row), mapError(err)
+}
+
+func (p *Postgres) UpdateUserSurname(ctx context.Context, userUUID uuid.UUID, surname string) (Details, error) {
+	row, err := p.queries.UpdateUserSurname(ctx, sqlc.UpdateUserSurnameParams{
+		UserUuid: userUUID,
+		Surname:  surname,
+	})
+	return detailsFromRow(row), mapError(err)
+}
+
+func (p *Postgres) UpdateUserPatronymic(ctx context.Context, userUUID uuid.UUID, patronymic string) (Details, error) {
+	row, err := p.queries.UpdateUserPatronymic(ctx, sqlc.UpdateUserPatronymicParams{
+		UserUuid:   userUUID,
+		Patronymic: pgtype.Text{String: patronymic, Valid: true},
+	})
+	return detailsFromRow(row), mapError(err)
+}
+
+func (p *Postgres) UpdateUserGroupCode(ctx context.Context, userUUID uuid.UUID, groupCode string) (Details, error) {
+	row, err := p.queries.UpdateUserGroupCode(ctx, sqlc.UpdateUserGroupCodeParams{
+		UserUuid:  userUUID,
+		GroupCode: groupCode,
+	})
+	return detailsFromRow(row), mapError(err)
+}
+
+func (p *Postgres) GetUserContacts(ctx context.Context, userUUID uuid.UUID) (Contacts, error) {
+	row, err := p.queries.GetUserContacts(ctx, userUUID)
+	return contactsFromRow(row), mapError(err)
+}
+
+func (p *Postgres) CreateUserContacts(ctx context.Context, contacts Contacts) (Contacts, error) {
+	row, err := p.queries.CreateUserContacts(ctx, sqlc.CreateUserContactsParams{
+		PhoneNumber: contacts.PhoneNumber,
+		Email:       textFromPtr(contacts.Email),
+		TelegramID:  int8FromPtr(contacts.TelegramID),
+		UserUuid:    contacts.UserUUID,
+	})
+	return contactsFromRow(row), mapError(err)
+}
+
+func (p *Postgres) UpdateUserPhoneNumber(ctx context.Context, userUUID uuid.UUID, phoneNumber string) (Contacts, error) {
+	row, err := p.queries.UpdateUserPhoneNumber(ctx, sqlc.UpdateUserPhoneNumberParams{
+		UserUuid:    userUUID,
+		PhoneNumber: phoneNumber,
+	})
+	return contactsFromRow(row), mapError(err)
+}
+
+func (p *Postgres) UpdateUserEmail(ctx context.Context, userUUID uuid.UUID, email string) (Contacts, error) {
+	row, err := p.queries.UpdateUserEmail(ctx, sqlc.UpdateUserEmailParams{
+		UserUuid: userUUID,
+		Email:    pgtype.Text{String: email, Valid: true},
+	})
+	return contactsFromRow(row), mapError(err)
+}
+
+func (p *Postgres) UpdateUserTelegramID(ctx context.Context, userUUID uuid.UUID, telegramID int64) (Contacts, error) {
+	row, err := p.queries.UpdateUserTelegramID(ctx, sqlc.UpdateUserTelegramIDParams{
+		UserUuid:   userUUID,
+		TelegramID: pgtype.Int8{Int64: telegramID, Valid: true},
+	})
+	return contactsFromRow(row), mapError(err)
+}
+
+// mapError translates driver errors into the repository sentinel errors,
+// keeping the original error in the chain for logging.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgForeignKeyViolation:
+			return fmt.Errorf("%w: %w", ErrNotFound, err)
+		case pgUniqueViolation:
+			return fmt.Errorf("%w: %w", ErrAlreadyExists, err)
+		case pgCheckViolation:
+			return fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+	}
+	return err
+}
+
+func detailsFromRow(row sqlc.UsersDetail) Details {
+	details := Details{
+		UserUUID:  row.UserUuid,
+		Name:      row.Name,
+		Surname:   row.Surname,
+		GroupCode: row.GroupCode,
+	}
+	if row.Patronymic.Valid {
+		details.Patronymic = &row.Patronymic.String
+	}
+	return details
+}
+
+func contactsFromRow(row sqlc.UsersContact) Contacts {
+	contacts := Contacts{
+		UserUUID:    row.UserUuid,
+		PhoneNumber: row.PhoneNumber,
+	}
+	if row.Email.Valid {
+		contacts.Email = &row.Email.String
+	}
+	if row.TelegramID.Valid {
+		contacts.TelegramID = &row.TelegramID.Int64
+	}
+	return contacts
+}
+
+func textFromPtr(s *string) pgtype.Text {
+	if s == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *s, Valid: true}
+}
+
+func int8FromPtr(i *int64) pgtype.Int8 {
+	if i == nil {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: *i, Valid: true}
+}
@@ -67,7 +67,7 @@ set telegram_id = $2
 where user_uuid = $1
 returning *;
 
--- name: DeleteUser :exec
+-- name: DeleteUser :execrows
 delete
 from users
 where uuid = $1;
\ No newline at end of file
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound is returned when the requested user, details or contacts
+	// do not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrAlreadyExists is returned when a row with the same key is present.
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrInvalid is returned when a value breaks a column constraint.
+	ErrInvalid = errors.New("invalid value")
+)
+
+type Details struct {
+	UserUUID   uuid.UUID
+	Name       string
+	Surname    string
+	Patronymic *string
+	GroupCode  string
+}
+
+type Contacts struct {
+	UserUUID    uuid.UUID
+	PhoneNumber string
+	Email       *string
+	TelegramID  *int64
+}
+
+// UserRepository is the storage used by the service. Implementations report
+// failures with ErrNotFound, ErrAlreadyExists and ErrInvalid so that callers
+// do not depend on a particular database driver.
+type UserRepository interface {
+	CreateUser(ctx context.Context, userUUID uuid.UUID) (uuid.UUID, error)
+	// DeleteUser reports whether the user existed.
+	DeleteUser(ctx context.Context, userUUID uuid.UUID) (bool, error)
+
+	GetUserDetails(ctx context.Context, userUUID uuid.UUID) (Details, error)
+	CreateUserDetails(ctx context.Context, details Details) (Details, error)
+	UpdateUserName(ctx context.Context, userUUID uuid.UUID, name string) (Details, error)
+	UpdateUserSurname(ctx context.Context, userUUID uuid.UUID, surname string) (Details, error)
+	UpdateUserPatronymic(ctx context.Context, userUUID uuid.UUID, patronymic string) (Details, error)
+	UpdateUserGroupCode(ctx context.Context, userUUID uuid.UUID, groupCode string) (Details, error)
+
+	GetUserContacts(ctx context.Context, userUUID uuid.UUID) (Contacts, error)
+	CreateUserContacts(ctx context.Context, contacts Contacts) (Contacts, error)
+	UpdateUserPhoneNumber(ctx context.Context, userUUID uuid.UUID, phoneNumber string) (Contacts, error)
+	UpdateUserEmail(ctx context.Context, userUUID uuid.UUID, email string) (Contacts, error)
+	UpdateUserTelegramID(ctx context.Context, userUUID uuid.UUID, telegramID int64) (Contacts, error)
+}
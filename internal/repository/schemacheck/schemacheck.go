@@ -0,0 +1,275 @@
+// Package schemacheck compares the live database schema with the one the
+// service was built for, so that manual changes are caught at startup rather
+// than as query errors at request time.
+package schemacheck
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"labgrab/user_service/internal/validation"
+)
+
+type Column struct {
+	Type     string
+	Nullable bool
+}
+
+type ForeignKey struct {
+	Columns           string
+	ReferencedTable   string
+	ReferencedColumns string
+	OnUpdate          string
+	OnDelete          string
+}
+
+type Table struct {
+	Columns     map[string]Column
+	Checks      map[string]string
+	ForeignKeys map[string]ForeignKey
+}
+
+// Schema maps table names in the public schema to their definition.
+type Schema map[string]Table
+
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+var userFK = ForeignKey{
+	Columns:           "user_uuid",
+	ReferencedTable:   "users",
+	ReferencedColumns: "uuid",
+	OnUpdate:          "CASCADE",
+	OnDelete:          "CASCADE",
+}
+
+// Expected returns the schema produced by the embedded migrations.
+func Expected() Schema {
+	schema := Schema{
+		"users": {
+			Columns: map[string]Column{
+				"uuid": {Type: "uuid"},
+			},
+			Checks:      map[string]string{},
+			ForeignKeys: map[string]ForeignKey{},
+		},
+		"users_details": {
+			Columns: map[string]Column{
+				"name":       {Type: "text"},
+				"surname":    {Type: "text"},
+				"patronymic": {Type: "text", Nullable: true},
+				"group_code": {Type: "text"},
+				"user_uuid":  {Type: "uuid"},
+			},
+			Checks:      map[string]string{},
+			ForeignKeys: map[string]ForeignKey{"user_uuid": userFK},
+		},
+		"users_contacts": {
+			Columns: map[string]Column{
+				"phone_number": {Type: "text"},
+				"email":        {Type: "text", Nullable: true},
+				"telegram_id":  {Type: "bigint", Nullable: true},
+				"user_uuid":    {Type: "uuid"},
+			},
+			Checks: map[string]string{
+				"telegram_id_check": "CHECK ((telegram_id > 0))",
+			},
+			ForeignKeys: map[string]ForeignKey{"user_uuid": userFK},
+		},
+	}
+
+	for _, c := range validation.Constraints {
+		schema[c.Table].Checks[c.Name] = fmt.Sprintf("CHECK ((%s ~ '%s'::text))",
+			c.Column, strings.ReplaceAll(c.Pattern.String(), "'", "''"))
+	}
+	return schema
+}
+
+// Inspect reads the definition of the tables listed in expected from
+// information_schema and pg_constraint.
+func Inspect(ctx context.Context, db Querier, expected Schema) (Schema, error) {
+	tables := make([]string, 0, len(expected))
+	for name := range expected {
+		tables = append(tables, name)
+	}
+
+	schema := Schema{}
+	table := func(name string) Table {
+		t, ok := schema[name]
+		if !ok {
+			t = Table{
+				Columns:     map[string]Column{},
+				Checks:      map[string]string{},
+				ForeignKeys: map[string]ForeignKey{},
+			}
+			schema[name] = t
+		}
+		return t
+	}
+
+	rows, err := db.Query(ctx, `
+		select table_name, column_name, data_type, is_nullable = 'YES'
+		from information_schema.columns
+		where table_schema = 'public' and table_name = any($1)`, tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+	err = scanRows(rows, func() error {
+		var tableName, columnName string
+		var column Column
+		if err := rows.Scan(&tableName, &columnName, &column.Type, &column.Nullable); err != nil {
+			return err
+		}
+		table(tableName).Columns[columnName] = column
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	rows, err = db.Query(ctx, `
+		select cls.relname, con.conname, con.contype::text, pg_get_constraintdef(con.oid),
+		       coalesce(ref.relname, ''),
+		       coalesce((select string_agg(a.attname, ',' order by k.ord)
+		                 from unnest(con.conkey) with ordinality k(attnum, ord)
+		                 join pg_attribute a on a.attrelid = con.conrelid and a.attnum = k.attnum), ''),
+		       coalesce((select string_agg(a.attname, ',' order by k.ord)
+		                 from unnest(con.confkey) with ordinality k(attnum, ord)
+		                 join pg_attribute a on a.attrelid = con.confrelid and a.attnum = k.attnum), ''),
+		       con.confupdtype::text, con.confdeltype::text
+		from pg_constraint con
+		join pg_class cls on cls.oid = con.conrelid
+		join pg_namespace ns on ns.oid = cls.relnamespace
+		left join pg_class ref on ref.oid = con.confrelid
+		where ns.nspname = 'public' and cls.relname = any($1) and con.contype in ('c', 'f')`, tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read constraints: %w", err)
+	}
+	err = scanRows(rows, func() error {
+		var tableName, name, kind, def, refTable, columns, refColumns, onUpdate, onDelete string
+		if err := rows.Scan(&tableName, &name, &kind, &def, &refTable, &columns, &refColumns, &onUpdate, &onDelete); err != nil {
+			return err
+		}
+		if kind == "c" {
+			table(tableName).Checks[name] = def
+			return nil
+		}
+		table(tableName).ForeignKeys[columns] = ForeignKey{
+			Columns:           columns,
+			ReferencedTable:   refTable,
+			ReferencedColumns: refColumns,
+			OnUpdate:          referentialAction(onUpdate),
+			OnDelete:          referentialAction(onDelete),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read constraints: %w", err)
+	}
+
+	return schema, nil
+}
+
+// Compare lists every difference between the expected and actual schema in
+// a stable order. An empty result means the schemas match. Extra nullable
+// columns are tolerated since the queries never touch them.
+func Compare(expected, actual Schema) []string {
+	var diffs []string
+
+	for _, tableName := range sortedKeys(expected) {
+		want := expected[tableName]
+		got, ok := actual[tableName]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("table %s is missing", tableName))
+			continue
+		}
+
+		for _, name := range sortedKeys(want.Columns) {
+			wantCol := want.Columns[name]
+			gotCol, ok := got.Columns[name]
+			switch {
+			case !ok:
+				diffs = append(diffs, fmt.Sprintf("column %s.%s is missing", tableName, name))
+			case gotCol.Type != wantCol.Type:
+				diffs = append(diffs, fmt.Sprintf("column %s.%s has type %s, expected %s", tableName, name, gotCol.Type, wantCol.Type))
+			case gotCol.Nullable != wantCol.Nullable:
+				diffs = append(diffs, fmt.Sprintf("column %s.%s nullable = %t, expected %t", tableName, name, gotCol.Nullable, wantCol.Nullable))
+			}
+		}
+		for _, name := range sortedKeys(got.Columns) {
+			if _, ok := want.Columns[name]; !ok && !got.Columns[name].Nullable {
+				diffs = append(diffs, fmt.Sprintf("unexpected not null column %s.%s", tableName, name))
+			}
+		}
+
+		for _, name := range sortedKeys(want.Checks) {
+			gotDef, ok := got.Checks[name]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("check constraint %s.%s is missing", tableName, name))
+			} else if gotDef != want.Checks[name] {
+				diffs = append(diffs, fmt.Sprintf("check constraint %s.%s is %s, expected %s", tableName, name, gotDef, want.Checks[name]))
+			}
+		}
+		for _, name := range sortedKeys(got.Checks) {
+			if _, ok := want.Checks[name]; !ok {
+				diffs = append(diffs, fmt.Sprintf("unexpected check constraint %s.%s: %s", tableName, name, got.Checks[name]))
+			}
+		}
+
+		for _, columns := range sortedKeys(want.ForeignKeys) {
+			gotFK, ok := got.ForeignKeys[columns]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("foreign key %s(%s) is missing", tableName, columns))
+			} else if gotFK != want.ForeignKeys[columns] {
+				diffs = append(diffs, fmt.Sprintf("foreign key %s(%s) is %+v, expected %+v", tableName, columns, gotFK, want.ForeignKeys[columns]))
+			}
+		}
+		for _, columns := range sortedKeys(got.ForeignKeys) {
+			if _, ok := want.ForeignKeys[columns]; !ok {
+				diffs = append(diffs, fmt.Sprintf("unexpected foreign key %s(%s)", tableName, columns))
+			}
+		}
+	}
+
+	return diffs
+}
+
+func scanRows(rows pgx.Rows, scan func() error) error {
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func referentialAction(code string) string {
+	switch code {
+	case "a":
+		return "NO ACTION"
+	case "r":
+		return "RESTRICT"
+	case "c":
+		return "CASCADE"
+	case "n":
+		return "SET NULL"
+	case "d":
+		return "SET DEFAULT"
+	}
+	return code
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
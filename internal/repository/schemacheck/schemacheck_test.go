@@ -0,0 +1,147 @@
+package schemacheck_test
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+
+	"labgrab/user_service/internal/repository/schemacheck"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(s schemacheck.Schema)
+		want   []string
+	}{
+		{
+			name:   "identical",
+			modify: func(s schemacheck.Schema) {},
+		},
+		{
+			name:   "missing table",
+			modify: func(s schemacheck.Schema) { delete(s, "users_contacts") },
+			want:   []string{"table users_contacts is missing"},
+		},
+		{
+			name:   "missing column",
+			modify: func(s schemacheck.Schema) { delete(s["users_details"].Columns, "patronymic") },
+			want:   []string{"column users_details.patronymic is missing"},
+		},
+		{
+			name: "changed type",
+			modify: func(s schemacheck.Schema) {
+				s["users_contacts"].Columns["telegram_id"] = schemacheck.Column{Type: "integer", Nullable: true}
+			},
+			want: []string{"column users_contacts.telegram_id has type integer, expected bigint"},
+		},
+		{
+			name: "changed nullability",
+			modify: func(s schemacheck.Schema) {
+				s["users_contacts"].Columns["email"] = schemacheck.Column{Type: "text"}
+			},
+			want: []string{"column users_contacts.email nullable = false, expected true"},
+		},
+		{
+			name: "extra nullable column",
+			modify: func(s schemacheck.Schema) {
+				s["users"].Columns["comment"] = schemacheck.Column{Type: "text", Nullable: true}
+			},
+		},
+		{
+			name: "extra not null column",
+			modify: func(s schemacheck.Schema) {
+				s["users"].Columns["created_at"] = schemacheck.Column{Type: "timestamp with time zone"}
+			},
+			want: []string{"unexpected not null column users.created_at"},
+		},
+		{
+			name:   "dropped check",
+			modify: func(s schemacheck.Schema) { delete(s["users_contacts"].Checks, "telegram_id_check") },
+			want:   []string{"check constraint users_contacts.telegram_id_check is missing"},
+		},
+		{
+			name: "changed check",
+			modify: func(s schemacheck.Schema) {
+				s["users_contacts"].Checks["telegram_id_check"] = "CHECK ((telegram_id >= 0))"
+			},
+			want: []string{"check constraint users_contacts.telegram_id_check is CHECK ((telegram_id >= 0)), expected CHECK ((telegram_id > 0))"},
+		},
+		{
+			name: "extra check",
+			modify: func(s schemacheck.Schema) {
+				s["users"].Checks["uuid_check"] = "CHECK ((uuid IS NOT NULL))"
+			},
+			want: []string{"unexpected check constraint users.uuid_check: CHECK ((uuid IS NOT NULL))"},
+		},
+		{
+			name:   "dropped foreign key",
+			modify: func(s schemacheck.Schema) { delete(s["users_details"].ForeignKeys, "user_uuid") },
+			want:   []string{"foreign key users_details(user_uuid) is missing"},
+		},
+		{
+			name: "changed on delete",
+			modify: func(s schemacheck.Schema) {
+				fk := s["users_details"].ForeignKeys["user_uuid"]
+				fk.OnDelete = "NO ACTION"
+				s["users_details"].ForeignKeys["user_uuid"] = fk
+			},
+			want: []string{"foreign key users_details(user_uuid) is " +
+				"{Columns:user_uuid ReferencedTable:users ReferencedColumns:uuid OnUpdate:CASCADE OnDelete:NO ACTION}, " +
+				"expected {Columns:user_uuid ReferencedTable:users ReferencedColumns:uuid OnUpdate:CASCADE OnDelete:CASCADE}"},
+		},
+		{
+			name: "several differences are sorted",
+			modify: func(s schemacheck.Schema) {
+				delete(s["users_details"].Columns, "surname")
+				delete(s["users_details"].Columns, "name")
+				delete(s["users"].Columns, "uuid")
+			},
+			want: []string{
+				"column users.uuid is missing",
+				"column users_details.name is missing",
+				"column users_details.surname is missing",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := schemacheck.Expected()
+			tt.modify(actual)
+
+			got := schemacheck.Compare(schemacheck.Expected(), actual)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestInspect runs only when TEST_DB_CONNECT points at a database with all
+// migrations applied; the live schema must then match Expected exactly.
+func TestInspect(t *testing.T) {
+	dsn := os.Getenv("TEST_DB_CONNECT")
+	if dsn == "" {
+		t.Skip("TEST_DB_CONNECT not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("Unable to connect to database: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	expected := schemacheck.Expected()
+	actual, err := schemacheck.Inspect(ctx, conn, expected)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	for _, diff := range schemacheck.Compare(expected, actual) {
+		t.Error(diff)
+	}
+}
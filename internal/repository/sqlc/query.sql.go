@@ -88,15 +88,18 @@ func (q *Queries) CreateUserDetails(ctx context.Context, arg CreateUserDetailsPa
 	return i, err
 }
 
-const deleteUser = `-- name: DeleteUser :exec
+const deleteUser = `-- name: DeleteUser :execrows
 delete
 from users
 where uuid = $1
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, argUuid uuid.UUID) error {
-	_, err := q.db.Exec(ctx, deleteUser, argUuid)
-	return err
+func (q *Queries) DeleteUser(ctx context.Context, argUuid uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUser, argUuid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const getUserContacts = `-- name: GetUserContacts :one
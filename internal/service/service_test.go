@@ -0,0 +1,195 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"labgrab/user_service/api/proto"
+	"labgrab/user_service/internal/interceptor"
+	"labgrab/user_service/internal/repository"
+	"labgrab/user_service/internal/service"
+	"labgrab/user_service/internal/testserver"
+)
+
+// fakeRepo implements repository.UserRepository; tests set only the methods
+// the handler under test calls, any other call panics on the nil interface.
+type fakeRepo struct {
+	repository.UserRepository
+
+	createUser          func(ctx context.Context, userUUID uuid.UUID) (uuid.UUID, error)
+	getUserDetails      func(ctx context.Context, userUUID uuid.UUID) (repository.Details, error)
+	createUserDetails   func(ctx context.Context, details repository.Details) (repository.Details, error)
+	getUserContacts     func(ctx context.Context, userUUID uuid.UUID) (repository.Contacts, error)
+	updateUserGroupCode func(ctx context.Context, userUUID uuid.UUID, groupCode string) (repository.Details, error)
+}
+
+func (f *fakeRepo) CreateUser(ctx context.Context, userUUID uuid.UUID) (uuid.UUID, error) {
+	return f.createUser(ctx, userUUID)
+}
+
+func (f *fakeRepo) GetUserDetails(ctx context.Context, userUUID uuid.UUID) (repository.Details, error) {
+	return f.getUserDetails(ctx, userUUID)
+}
+
+func (f *fakeRepo) CreateUserDetails(ctx context.Context, details repository.Details) (repository.Details, error) {
+	return f.createUserDetails(ctx, details)
+}
+
+func (f *fakeRepo) GetUserContacts(ctx context.Context, userUUID uuid.UUID) (repository.Contacts, error) {
+	return f.getUserContacts(ctx, userUUID)
+}
+
+func (f *fakeRepo) UpdateUserGroupCode(ctx context.Context, userUUID uuid.UUID, groupCode string) (repository.Details, error) {
+	return f.updateUserGroupCode(ctx, userUUID, groupCode)
+}
+
+// newService serves the service behind the common interceptors, which
+// translate repository errors into status codes, and returns a client.
+func newService(t *testing.T, repo *fakeRepo) proto.UserServiceClient {
+	t.Helper()
+
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptor.UnaryCommon(zap.NewNop())...))
+	proto.RegisterUserServiceServer(s, &service.Service{Repo: repo})
+	conn, err := grpc.NewClient(testserver.Target,
+		testserver.Serve(t, s),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return proto.NewUserServiceClient(conn)
+}
+
+func assertCode(t *testing.T, err error, want grpccodes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("code = %v, want %v (err: %v)", got, want, err)
+	}
+}
+
+var testUUID = uuid.MustParse("e4b2a4a4-9a6e-4d0b-8f8e-6f1f3f0c2a11")
+
+func TestCreateUserAlreadyExists(t *testing.T) {
+	svc := newService(t, &fakeRepo{
+		createUser: func(ctx context.Context, userUUID uuid.UUID) (uuid.UUID, error) {
+			return uuid.Nil, repository.ErrAlreadyExists
+		},
+	})
+
+	_, err := svc.CreateUser(context.Background(), &proto.CreateUserRequest{Uuid: testUUID.String()})
+	assertCode(t, err, grpccodes.AlreadyExists)
+}
+
+func TestGetUserDetailsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoErr error
+		want    grpccodes.Code
+	}{
+		{name: "not found", repoErr: repository.ErrNotFound, want: grpccodes.NotFound},
+		{name: "wrapped not found", repoErr: errors.Join(repository.ErrNotFound, errors.New("no rows")), want: grpccodes.NotFound},
+		{name: "database failure", repoErr: errors.New("connection refused"), want: grpccodes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newService(t, &fakeRepo{
+				getUserDetails: func(ctx context.Context, userUUID uuid.UUID) (repository.Details, error) {
+					return repository.Details{}, tt.repoErr
+				},
+			})
+
+			_, err := svc.GetUserDetails(context.Background(), &proto.GetUserDetailsRequest{UserUuid: testUUID.String()})
+			assertCode(t, err, tt.want)
+		})
+	}
+}
+
+func TestCreateUserDetailsPassesOptionalPatronymic(t *testing.T) {
+	patronymic := "Петрович"
+	var stored repository.Details
+	svc := newService(t, &fakeRepo{
+		createUserDetails: func(ctx context.Context, details repository.Details) (repository.Details, error) {
+			stored = details
+			return details, nil
+		},
+	})
+
+	resp, err := svc.CreateUserDetails(context.Background(), &proto.CreateUserDetailsRequest{
+		Name:       "Иван",
+		Surname:    "Иванов",
+		Patronymic: &patronymic,
+		GroupCode:  "ИТ-1-1",
+		UserUuid:   testUUID.String(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stored.UserUUID != testUUID || stored.Patronymic == nil || *stored.Patronymic != patronymic {
+		t.Errorf("repository got %+v", stored)
+	}
+	if resp.Details.GetPatronymic() != patronymic || resp.Details.UserUuid != testUUID.String() {
+		t.Errorf("response details = %+v", resp.Details)
+	}
+}
+
+func TestCreateUserDetailsUnknownUser(t *testing.T) {
+	svc := newService(t, &fakeRepo{
+		createUserDetails: func(ctx context.Context, details repository.Details) (repository.Details, error) {
+			return repository.Details{}, repository.ErrNotFound
+		},
+	})
+
+	_, err := svc.CreateUserDetails(context.Background(), &proto.CreateUserDetailsRequest{
+		Name:      "Иван",
+		Surname:   "Иванов",
+		GroupCode: "ИТ-1-1",
+		UserUuid:  testUUID.String(),
+	})
+	assertCode(t, err, grpccodes.NotFound)
+}
+
+func TestGetUserContactsMapsOptionalFields(t *testing.T) {
+	email := "ivanov@example.com"
+	svc := newService(t, &fakeRepo{
+		getUserContacts: func(ctx context.Context, userUUID uuid.UUID) (repository.Contacts, error) {
+			return repository.Contacts{UserUUID: userUUID, PhoneNumber: "+79991234567", Email: &email}, nil
+		},
+	})
+
+	resp, err := svc.GetUserContacts(context.Background(), &proto.GetUserContactsRequest{UserUuid: testUUID.String()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Contacts.GetEmail() != email {
+		t.Errorf("email = %q, want %q", resp.Contacts.GetEmail(), email)
+	}
+	if resp.Contacts.TelegramId != nil {
+		t.Errorf("telegram ID = %d, want unset", *resp.Contacts.TelegramId)
+	}
+}
+
+func TestUpdateUserGroupCodeNotFound(t *testing.T) {
+	svc := newService(t, &fakeRepo{
+		updateUserGroupCode: func(ctx context.Context, userUUID uuid.UUID, groupCode string) (repository.Details, error) {
+			return repository.Details{}, repository.ErrNotFound
+		},
+	})
+
+	_, err := svc.UpdateUserGroupCode(context.Background(), &proto.UpdateUserGroupCodeRequest{
+		UserUuid:  testUUID.String(),
+		GroupCode: "ИТ-1-1",
+	})
+	assertCode(t, err, grpccodes.NotFound)
+}
@@ -0,0 +1,52 @@
+// Package testserver runs the UserService the way main does, behind the
+// common interceptors and request validation, over an in-memory repository,
+// for the tests of the gateway, the web handler and userctl.
+package testserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"buf.build/go/protovalidate"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+
+	"labgrab/user_service/api/proto"
+	"labgrab/user_service/internal/interceptor"
+	"labgrab/user_service/internal/repository"
+	"labgrab/user_service/internal/service"
+)
+
+// Target is the address to dial with the option returned by Serve.
+const Target = "passthrough:///bufnet"
+
+// New returns a server with the service registered, not yet serving.
+func New(t testing.TB) *grpc.Server {
+	t.Helper()
+
+	validator, err := protovalidate.New()
+	if err != nil {
+		t.Fatalf("protovalidate.New: %v", err)
+	}
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		append(interceptor.UnaryCommon(zap.NewNop()), interceptor.UnaryValidator(validator))...,
+	))
+	proto.RegisterUserServiceServer(s, &service.Service{Repo: repository.NewMemory()})
+	return s
+}
+
+// Serve serves s on an in-memory listener until the test ends and returns
+// the dial option connecting to it.
+func Serve(t testing.TB, s *grpc.Server) grpc.DialOption {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	})
+}
@@ -0,0 +1,235 @@
+// Command gen turns rules.json into Go validators and SQL CHECK constraints.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type rule struct {
+	Name        string   `json:"name"`
+	Pattern     string   `json:"pattern"`
+	Columns     []string `json:"columns"`
+	ProtoFields []string `json:"proto_fields"`
+}
+
+type column struct {
+	table string
+	name  string
+	rule  rule
+}
+
+func main() {
+	rulesPath := flag.String("rules", "rules.json", "rule definition file")
+	goPath := flag.String("go", "rules_gen.go", "generated Go output")
+	migrationsDir := flag.String("migrations", "migrations", "directory of the SQL migrations")
+	migrationName := flag.String("name", "check_constraints", "name of the generated migrations")
+	flag.Parse()
+
+	data, err := os.ReadFile(*rulesPath)
+	if err != nil {
+		log.Fatalf("Failed to read rules: %v", err)
+	}
+
+	var def struct {
+		Rules []rule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &def); err != nil {
+		log.Fatalf("Failed to parse rules: %v", err)
+	}
+
+	var columns []column
+	for _, r := range def.Rules {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			log.Fatalf("Rule %s has invalid pattern: %v", r.Name, err)
+		}
+		for _, c := range r.Columns {
+			table, name, ok := strings.Cut(c, ".")
+			if !ok {
+				log.Fatalf("Rule %s: column %q must be table.column", r.Name, c)
+			}
+			columns = append(columns, column{table: table, name: name, rule: r})
+		}
+	}
+
+	goSrc, err := format.Source(generateGo(def.Rules, columns))
+	if err != nil {
+		log.Fatalf("Failed to format Go output: %v", err)
+	}
+	if err := os.WriteFile(*goPath, goSrc, 0o644); err != nil {
+		log.Fatalf("Failed to write %s: %v", *goPath, err)
+	}
+	if err := writeMigration(*migrationsDir, *migrationName, columns); err != nil {
+		log.Fatalf("Failed to write migration: %v", err)
+	}
+}
+
+var migrationRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.up\.sql$`)
+
+// writeMigration adds the constraints as the next migration in dir, unless
+// the last migration generated under name already applies them. Applied
+// migrations are checksummed, so an existing one is never rewritten. The
+// down migration restores the constraints of the previous generated one.
+func writeMigration(dir, name string, columns []column) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var (
+		last, width   = 0, 4
+		lastGenerated string
+	)
+	for _, entry := range entries {
+		m := migrationRegexp.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.Atoi(m[1])
+		if err != nil {
+			return fmt.Errorf("invalid migration version in %q: %w", entry.Name(), err)
+		}
+		if version > last {
+			last, width = version, len(m[1])
+		}
+		// ReadDir sorts by name, so the last match is the latest.
+		if m[2] == name {
+			lastGenerated = entry.Name()
+		}
+	}
+
+	up := generateSQL(columns)
+	down := generateSQLDown(columns)
+	if lastGenerated != "" {
+		prev, err := os.ReadFile(filepath.Join(dir, lastGenerated))
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(prev, up) {
+			return nil
+		}
+		down = prev
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%0*d_%s", width, last+1, name))
+	if err := os.WriteFile(base+".up.sql", up, 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(base+".down.sql", down, 0o644); err != nil {
+		return err
+	}
+	log.Printf("Wrote %s.up.sql and %s.down.sql", base, base)
+	return nil
+}
+
+func generateGo(rules []rule, columns []column) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("// Code generated by internal/validation/gen from rules.json. DO NOT EDIT.\n\n")
+	b.WriteString("package validation\n\n")
+	b.WriteString("import \"regexp\"\n\n")
+
+	b.WriteString("var (\n")
+	for _, r := range rules {
+		fmt.Fprintf(&b, "%sRegexp = regexp.MustCompile(%s)\n", r.Name, goString(r.Pattern))
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// Constraints lists the CHECK constraints generated into the SQL migration.\n")
+	b.WriteString("var Constraints = []Constraint{\n")
+	for _, c := range columns {
+		fmt.Fprintf(&b, "{Name: %q, Table: %q, Column: %q, Pattern: %sRegexp},\n",
+			constraintName(c), c.table, c.name, c.rule.Name)
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// ProtoFieldPatterns maps request field names to the pattern their\n")
+	b.WriteString("// buf.validate annotation in user.proto must declare.\n")
+	b.WriteString("var ProtoFieldPatterns = map[string]*regexp.Regexp{\n")
+	for _, r := range rules {
+		for _, f := range r.ProtoFields {
+			fmt.Fprintf(&b, "%q: %sRegexp,\n", f, r.Name)
+		}
+	}
+	b.WriteString("}\n")
+
+	return b.Bytes()
+}
+
+func generateSQL(columns []column) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("-- Code generated by internal/validation/gen from rules.json. DO NOT EDIT.\n")
+	b.WriteString("-- Existing constraints are dropped first, so the same output can be\n")
+	b.WriteString("-- written to a new migration whenever a rule changes.\n")
+
+	tables, byTable := groupByTable(columns)
+	for _, table := range tables {
+		fmt.Fprintf(&b, "\nalter table public.%s\n", table)
+		cols := byTable[table]
+		for i, c := range cols {
+			fmt.Fprintf(&b, "    drop constraint if exists %s,\n", constraintName(c))
+			fmt.Fprintf(&b, "    add constraint %s check ((%s ~ '%s'::text))",
+				constraintName(c), c.name, strings.ReplaceAll(c.rule.Pattern, "'", "''"))
+			if i < len(cols)-1 {
+				b.WriteString(",\n")
+			}
+		}
+		b.WriteString(";\n")
+	}
+
+	return b.Bytes()
+}
+
+func generateSQLDown(columns []column) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("-- Code generated by internal/validation/gen from rules.json. DO NOT EDIT.\n")
+
+	tables, byTable := groupByTable(columns)
+	for _, table := range tables {
+		fmt.Fprintf(&b, "\nalter table public.%s\n", table)
+		cols := byTable[table]
+		for i, c := range cols {
+			fmt.Fprintf(&b, "    drop constraint if exists %s", constraintName(c))
+			if i < len(cols)-1 {
+				b.WriteString(",\n")
+			}
+		}
+		b.WriteString(";\n")
+	}
+
+	return b.Bytes()
+}
+
+func groupByTable(columns []column) ([]string, map[string][]column) {
+	var tables []string
+	byTable := map[string][]column{}
+	for _, c := range columns {
+		if _, ok := byTable[c.table]; !ok {
+			tables = append(tables, c.table)
+		}
+		byTable[c.table] = append(byTable[c.table], c)
+	}
+	return tables, byTable
+}
+
+func constraintName(c column) string {
+	return c.name + "_check"
+}
+
+func goString(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
@@ -0,0 +1,30 @@
+// Code generated by internal/validation/gen from rules.json. DO NOT EDIT.
+
+package validation
+
+import "regexp"
+
+var (
+	alphabeticRegexp  = regexp.MustCompile(`^[\p{L}\_\-\. ]+$`)
+	groupCodeRegexp   = regexp.MustCompile(`^\p{L}{2,3}\-[0-9]{1,2}\-[0-9]{1,2}$`)
+	phoneNumberRegexp = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+)
+
+// Constraints lists the CHECK constraints generated into the SQL migration.
+var Constraints = []Constraint{
+	{Name: "name_check", Table: "users_details", Column: "name", Pattern: alphabeticRegexp},
+	{Name: "surname_check", Table: "users_details", Column: "surname", Pattern: alphabeticRegexp},
+	{Name: "patronymic_check", Table: "users_details", Column: "patronymic", Pattern: alphabeticRegexp},
+	{Name: "group_code_check", Table: "users_details", Column: "group_code", Pattern: groupCodeRegexp},
+	{Name: "phone_number_check", Table: "users_contacts", Column: "phone_number", Pattern: phoneNumberRegexp},
+}
+
+// ProtoFieldPatterns maps request field names to the pattern their
+// buf.validate annotation in user.proto must declare.
+var ProtoFieldPatterns = map[string]*regexp.Regexp{
+	"name":         alphabeticRegexp,
+	"surname":      alphabeticRegexp,
+	"patronymic":   alphabeticRegexp,
+	"group_code":   groupCodeRegexp,
+	"phone_number": phoneNumberRegexp,
+}
@@ -0,0 +1,23 @@
+// Package validation holds the field rules shared by the Go code and the
+// database. The regex rules are defined once in rules.json; the Go patterns
+// in rules_gen.go and the CHECK constraint migrations are generated from it.
+//
+// Applied migrations must not change, so a changed rule is written to the
+// next free migration version rather than over the previous one.
+package validation
+
+//go:generate go run ./gen -rules rules.json -go rules_gen.go -migrations ../repository/migrations
+
+import "regexp"
+
+// Constraint is a CHECK constraint that restricts a column to a regex rule.
+type Constraint struct {
+	Name    string
+	Table   string
+	Column  string
+	Pattern *regexp.Regexp
+}
+
+func ValidateTelegramID(telegramID int64) bool {
+	return telegramID > 0
+}
@@ -0,0 +1,117 @@
+package validation_test
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"buf.build/go/protovalidate"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"labgrab/user_service/api/proto"
+	"labgrab/user_service/internal/validation"
+)
+
+func TestProtoPatternsMatchRules(t *testing.T) {
+	seen := map[string]bool{}
+
+	messages := proto.File_user_proto.Messages()
+	for i := 0; i < messages.Len(); i++ {
+		fields := messages.Get(i).Fields()
+		for j := 0; j < fields.Len(); j++ {
+			field := fields.Get(j)
+			if field.Kind() != protoreflect.StringKind {
+				continue
+			}
+
+			rules, err := protovalidate.ResolveFieldRules(field)
+			if err != nil {
+				t.Fatalf("ResolveFieldRules(%s): %v", field.FullName(), err)
+			}
+			if !rules.GetString().HasPattern() {
+				continue
+			}
+
+			name := string(field.Name())
+			want, ok := validation.ProtoFieldPatterns[name]
+			if !ok {
+				t.Errorf("%s declares a pattern but rules.json has no rule for %q", field.FullName(), name)
+				continue
+			}
+			if got := rules.GetString().GetPattern(); got != want.String() {
+				t.Errorf("%s pattern = %q, rules.json = %q", field.FullName(), got, want.String())
+			}
+			seen[name] = true
+		}
+	}
+
+	for name := range validation.ProtoFieldPatterns {
+		if !seen[name] {
+			t.Errorf("rules.json lists proto field %q but no request field declares its pattern", name)
+		}
+	}
+}
+
+var checkPatternRegexp = regexp.MustCompile(`~ '((?:[^']|'')*)'::text`)
+
+// TestLiveSchemaConstraints compares the CHECK constraints of a migrated
+// database with the generated Go rules. It runs only when TEST_DB_CONNECT
+// points at a database with all migrations applied.
+func TestLiveSchemaConstraints(t *testing.T) {
+	dsn := os.Getenv("TEST_DB_CONNECT")
+	if dsn == "" {
+		t.Skip("TEST_DB_CONNECT not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("Unable to connect to database: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	rows, err := conn.Query(ctx, `
+		select cls.relname, con.conname, pg_get_constraintdef(con.oid)
+		from pg_constraint con
+		join pg_class cls on cls.oid = con.conrelid
+		join pg_namespace ns on ns.oid = cls.relnamespace
+		where con.contype = 'c'
+		  and ns.nspname = 'public'
+		  and cls.relname in ('users_details', 'users_contacts')`)
+	if err != nil {
+		t.Fatalf("Failed to query constraints: %v", err)
+	}
+
+	live := map[string]string{}
+	for rows.Next() {
+		var table, name, def string
+		if err := rows.Scan(&table, &name, &def); err != nil {
+			t.Fatalf("Failed to scan constraint: %v", err)
+		}
+		if m := checkPatternRegexp.FindStringSubmatch(def); m != nil {
+			live[table+"."+name] = strings.ReplaceAll(m[1], "''", "'")
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Failed to read constraints: %v", err)
+	}
+
+	for _, c := range validation.Constraints {
+		key := c.Table + "." + c.Name
+		got, ok := live[key]
+		if !ok {
+			t.Errorf("constraint %s is missing from the database", key)
+			continue
+		}
+		if got != c.Pattern.String() {
+			t.Errorf("constraint %s pattern = %q, Go rule = %q", key, got, c.Pattern.String())
+		}
+		delete(live, key)
+	}
+	for key, pattern := range live {
+		t.Errorf("database has regex constraint %s (%q) that rules.json does not define", key, pattern)
+	}
+}
@@ -0,0 +1,88 @@
+// Package web serves the gRPC services to browsers over the Connect and
+// gRPC-Web protocols. Requests are transcoded to gRPC in process, so they go
+// through the same interceptors as native gRPC calls.
+package web
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"connectrpc.com/vanguard/vanguardgrpc"
+	"google.golang.org/grpc"
+)
+
+var (
+	allowedHeaders = strings.Join([]string{
+		"Content-Type", "Accept-Language", "Authorization",
+		"Connect-Protocol-Version", "Connect-Timeout-Ms",
+		"Grpc-Timeout", "X-Grpc-Web", "X-User-Agent",
+	}, ", ")
+	exposedHeaders = strings.Join([]string{
+		"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin",
+	}, ", ")
+)
+
+// NewHandler returns a handler serving every service registered on s, so it
+// must be called after registration. Cross-origin requests are allowed from
+// allowedOrigins; "*" allows any origin.
+//
+// JSON requests are transcoded to the binary format before they reach s. A
+// JSON codec registered with gRPC would save that step, but the registry is
+// global, so s would accept application/grpc+json from native clients too.
+func NewHandler(s *grpc.Server, allowedOrigins []string) (http.Handler, error) {
+	transcoder, err := vanguardgrpc.NewTranscoder(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transcoder: %w", err)
+	}
+	return cors(transcoder, allowedOrigins), nil
+}
+
+// NewServer returns an HTTP server for handler accepting both HTTP/1.1 and
+// HTTP/2, as browsers and Connect clients use either. Without tlsConfig it
+// serves both without TLS; otherwise it must be started with
+// ListenAndServeTLS("", "").
+func NewServer(addr string, handler http.Handler, tlsConfig *tls.Config) *http.Server {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if tlsConfig != nil {
+		protocols.SetHTTP2(true)
+	} else {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		Protocols:         protocols,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+func cors(next http.Handler, allowedOrigins []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !(slices.Contains(allowedOrigins, "*") || slices.Contains(allowedOrigins, origin)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Expose-Headers", exposedHeaders)
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, POST")
+			h.Set("Access-Control-Allow-Headers", allowedHeaders)
+			h.Set("Access-Control-Max-Age", "7200")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
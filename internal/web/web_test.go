@@ -0,0 +1,156 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/vanguard"
+	"connectrpc.com/vanguard/vanguardgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	protobuf "google.golang.org/protobuf/proto"
+
+	"labgrab/user_service/api/proto"
+	"labgrab/user_service/internal/testserver"
+	"labgrab/user_service/internal/web"
+)
+
+const testUUID = "0b7f9c1e-4c1a-4d6e-9f6b-2a8d3c5e7f10"
+
+func newServer(t *testing.T, allowedOrigins ...string) *httptest.Server {
+	t.Helper()
+
+	handler, err := web.NewHandler(testserver.New(t), allowedOrigins)
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, srv *httptest.Server, path, contentType string, body []byte) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if contentType == "application/json" {
+		req.Header.Set("Connect-Protocol-Version", "1")
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return resp, data
+}
+
+func TestConnectJSON(t *testing.T) {
+	srv := newServer(t)
+
+	resp, body := post(t, srv, "/proto.UserService/CreateUser", "application/json", []byte(`{"uuid":"`+testUUID+`"}`))
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), testUUID) {
+		t.Fatalf("CreateUser = %d %s", resp.StatusCode, body)
+	}
+
+	resp, body = post(t, srv, "/proto.UserService/CreateUser", "application/json", []byte(`{"uuid":"bad"}`))
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), "invalid_argument") {
+		t.Errorf("CreateUser with a bad UUID = %d %s, want 400 invalid_argument", resp.StatusCode, body)
+	}
+}
+
+func TestNativeServerRejectsJSON(t *testing.T) {
+	s := testserver.New(t)
+	if _, err := web.NewHandler(s, nil); err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	conn, err := grpc.NewClient(testserver.Target,
+		testserver.Serve(t, s),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer conn.Close()
+
+	_, err = proto.NewUserServiceClient(conn).CreateUser(context.Background(),
+		&proto.CreateUserRequest{Uuid: testUUID},
+		grpc.ForceCodec(vanguardgrpc.NewCodec(&vanguard.JSONCodec{})))
+	if err == nil {
+		t.Error("CreateUser over application/grpc+json succeeded, want the server to accept only proto")
+	}
+}
+
+func TestGRPCWeb(t *testing.T) {
+	srv := newServer(t)
+
+	msg, err := protobuf.Marshal(&proto.CreateUserRequest{Uuid: testUUID})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	frame = append(frame, msg...)
+
+	resp, body := post(t, srv, "/proto.UserService/CreateUser", "application/grpc-web+proto", frame)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("CreateUser = %d", resp.StatusCode)
+	}
+	if len(body) < 5 || body[0] != 0 {
+		t.Fatalf("response does not start with a message frame: %q", body)
+	}
+	n := binary.BigEndian.Uint32(body[1:5])
+	var created proto.CreateUserResponse
+	if err := protobuf.Unmarshal(body[5:5+n], &created); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if created.GetUser().GetUuid() != testUUID {
+		t.Errorf("created uuid = %q, want %q", created.GetUser().GetUuid(), testUUID)
+	}
+	if trailer := strings.ToLower(string(body[5+n:])); !strings.Contains(trailer, "grpc-status: 0") {
+		t.Errorf("trailer frame = %q, want grpc-status: 0", trailer)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	srv := newServer(t, "https://app.example.com")
+
+	tests := []struct {
+		origin string
+		want   string
+	}{
+		{"https://app.example.com", "https://app.example.com"},
+		{"https://evil.example.com", ""},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodOptions, srv.URL+"/proto.UserService/CreateUser", nil)
+		if err != nil {
+			t.Fatalf("NewRequest: %v", err)
+		}
+		req.Header.Set("Origin", tt.origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("OPTIONS: %v", err)
+		}
+		resp.Body.Close()
+
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != tt.want {
+			t.Errorf("Access-Control-Allow-Origin for %s = %q, want %q", tt.origin, got, tt.want)
+		}
+	}
+}
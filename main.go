@@ -2,73 +2,186 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"buf.build/go/protovalidate"
 	"github.com/exaring/otelpgx"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	"labgrab/user_service/api/proto"
-	"labgrab/user_service/internal/repository/sqlc"
+	"labgrab/user_service/internal/admin"
+	"labgrab/user_service/internal/auth"
+	"labgrab/user_service/internal/gateway"
+	"labgrab/user_service/internal/health"
+	"labgrab/user_service/internal/interceptor"
+	"labgrab/user_service/internal/mtls"
+	"labgrab/user_service/internal/ratelimit"
+	"labgrab/user_service/internal/repository"
 	"labgrab/user_service/internal/service"
+	"labgrab/user_service/internal/web"
 	"labgrab/user_service/pkg/config"
 	"labgrab/user_service/pkg/logger"
+	"labgrab/user_service/pkg/redact"
 	"labgrab/user_service/pkg/telemetry"
 )
 
+// publicMethods can be called without a token: probes and the debugging
+// services, which are only exposed in DEV or on the admin listener.
+var publicMethods = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.",
+	"/grpc.channelz.",
+}
+
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	cfg, err := config.Load()
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config `file`, environment variables take precedence")
+	printConfig := flag.Bool("print-config", false, "print the effective config with secrets masked and exit")
+	flag.Parse()
+
+	// Migrations only need the database, not the settings of the server.
+	if flag.Arg(0) == "migrate" {
+		cfg, err := config.LoadDatabase(*configFile)
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		if err := runMigrate(ctx, cfg, flag.Args()[1:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	cfg, err := config.Load(*configFile)
 	if err != nil {
-		log.Fatalf("Failed to parse .env: %v", err)
+		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	tp, err := telemetry.InitTracer(&telemetry.Config{
-		ServiceName:    cfg.ServiceName,
-		JaegerEndpoint: cfg.JaegerEndpoint,
-		Environment:    string(cfg.Environment),
-	})
+	if *printConfig {
+		if err := config.Print(os.Stdout, cfg); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
+		}
+		return
+	}
 
+	redactor, err := redact.New(cfg.Redaction, []byte(cfg.PIIHashKey))
 	if err != nil {
-		log.Fatalf("Failed to initialize tracer: %v", err)
+		log.Fatalf("Failed to create redactor: %v", err)
 	}
 
-	zapLogger := logger.Logger(&logger.Options{
-		Path:       "logs/user-service.log",
-		MaxSize:    100,
-		MaxBackups: 3,
-		MaxAge:     28,
-		Compress:   true,
+	tp, err := telemetry.InitTracer(ctx, &telemetry.Config{
+		ServiceName: cfg.ServiceName,
+		Environment: string(cfg.Environment),
+		Exporter:    string(cfg.TracesExporter),
+		Protocol:    string(cfg.TracesProtocol),
+		Redactor:    redactor,
 	})
-	defer zapLogger.Sync()
 
-	pgconfig, err := pgxpool.ParseConfig(cfg.DBConn)
 	if err != nil {
-		log.Fatalf("Failed to parse DB connection string: %v", err)
+		log.Fatalf("Failed to initialize tracer: %v", err)
 	}
 
-	pgconfig.ConnConfig.Tracer = otelpgx.NewTracer(
-		otelpgx.WithTrimSQLInSpanName(),
+	// Without METRICS_PORT the global meter provider stays a no-op.
+	var (
+		mp             *sdkmetric.MeterProvider
+		metricsHandler http.Handler
 	)
+	if cfg.MetricsPort != 0 {
+		mp, metricsHandler, err = telemetry.InitMeter(&telemetry.Config{
+			ServiceName: cfg.ServiceName,
+			Environment: string(cfg.Environment),
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize metrics: %v", err)
+		}
+	}
 
-	conn, err := pgxpool.NewWithConfig(ctx, pgconfig)
+	lp, err := telemetry.InitLogger(ctx, &telemetry.Config{
+		ServiceName: cfg.ServiceName,
+		Environment: string(cfg.Environment),
+		Exporter:    string(cfg.LogsExporter),
+		Protocol:    string(cfg.LogsProtocol),
+	})
 	if err != nil {
-		log.Fatalf("Unable to connect to database: %v", err)
+		log.Fatalf("Failed to initialize log exporter: %v", err)
+	}
+	logLevel := zap.NewAtomicLevelAt(cfg.LogLevel)
+	logOptions := &logger.Options{
+		Level:       logLevel,
+		Stdout:      cfg.LogOutput != config.LogOutputFile,
+		Development: cfg.Environment == config.Development,
+		MaxSize:     cfg.LogMaxSize,
+		MaxBackups:  cfg.LogMaxBackups,
+		MaxAge:      cfg.LogMaxAge,
+		Compress:    cfg.LogCompress,
+		Redactor:    redactor,
+	}
+	if cfg.LogOutput != config.LogOutputStdout {
+		logOptions.Path = cfg.LogFile
+	}
+	// A nil provider must not end up as a non-nil interface.
+	if lp != nil {
+		logOptions.LoggerProvider = lp
+	}
+	zapLogger := logger.Logger(logOptions)
+	defer zapLogger.Sync()
+	logger.ToggleDebug(ctx, logLevel, zapLogger, syscall.SIGUSR1)
+
+	var (
+		repo   repository.UserRepository
+		pinger health.Pinger
+	)
+	switch cfg.Storage {
+	case config.StorageMemory:
+		log.Println("Using in-memory storage, data will be lost on shutdown")
+		repo = repository.NewMemory()
+	default:
+		conn, err := newPool(ctx, cfg)
+		if err != nil {
+			log.Fatalf("Unable to connect to database: %v", err)
+		}
+		defer conn.Close()
+
+		if err := prepareSchema(ctx, cfg, conn); err != nil {
+			log.Fatalf("Failed to prepare database schema: %v", err)
+		}
+		if err := checkSchema(ctx, cfg, conn); err != nil {
+			log.Fatalf("Database schema check failed: %v", err)
+		}
+
+		if err := otelpgx.RecordStats(conn); err != nil {
+			log.Fatalf("Failed to record database pool metrics: %v", err)
+		}
+
+		repo = repository.NewPostgres(conn)
+		pinger = conn
 	}
-	defer conn.Close()
 
-	repo := sqlc.New(conn)
 	svc := &service.Service{
-		Logger: zapLogger,
-		Repo:   repo,
+		Repo: repo,
+	}
+
+	validator, err := protovalidate.New()
+	if err != nil {
+		log.Fatalf("Failed to create request validator: %v", err)
 	}
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
@@ -76,24 +189,293 @@ func main() {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	s := grpc.NewServer(
-		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	// After the common interceptors, authentication comes first so that
+	// rate limits can be kept per subject, and rate limiting before
+	// authorization, whose group leader rule queries the database.
+	var (
+		unaryInterceptors  = interceptor.UnaryCommon(zapLogger)
+		streamInterceptors = interceptor.StreamCommon(zapLogger)
+		authorizer         *auth.Authorizer
 	)
+	if cfg.AuthJWKS != "" {
+		verifier, err := auth.NewVerifier(ctx, auth.Options{
+			JWKS:            cfg.AuthJWKS,
+			Issuer:          cfg.AuthIssuer,
+			Audience:        cfg.AuthAudience,
+			RefreshInterval: cfg.AuthRefresh,
+			Logger:          zapLogger,
+		})
+		if err != nil {
+			log.Fatalf("Failed to load JWKS: %v", err)
+		}
+
+		policy := auth.DefaultPolicy()
+		if cfg.AuthPolicy != "" {
+			if policy, err = auth.LoadPolicy(cfg.AuthPolicy); err != nil {
+				log.Fatalf("Failed to load authorization policy: %v", err)
+			}
+		}
+		authorizer = auth.NewAuthorizer(policy, groupLookup(repo))
+
+		unaryInterceptors = append(unaryInterceptors, interceptor.UnaryAuth(verifier, publicMethods...))
+		streamInterceptors = append(streamInterceptors, interceptor.StreamAuth(verifier, publicMethods...))
+	} else {
+		log.Println("AUTH_JWKS is not set, requests are not authenticated")
+	}
+	if cfg.RateLimits != "" {
+		limits, err := ratelimit.ParseLimits(cfg.RateLimits)
+		if err != nil {
+			log.Fatalf("RATE_LIMITS: %v", err)
+		}
+		limiter := ratelimit.New(limits)
+		unaryInterceptors = append(unaryInterceptors, interceptor.UnaryRateLimit(limiter, publicMethods...))
+		streamInterceptors = append(streamInterceptors, interceptor.StreamRateLimit(limiter, publicMethods...))
+	}
+	if authorizer != nil {
+		unaryInterceptors = append(unaryInterceptors, interceptor.UnaryAuthorize(authorizer, zapLogger, publicMethods...))
+		streamInterceptors = append(streamInterceptors, interceptor.StreamAuthorize(authorizer, zapLogger, publicMethods...))
+	}
+	unaryInterceptors = append(unaryInterceptors, interceptor.UnaryValidator(validator))
+
+	// Without TLS_CERT_FILE the listeners serve plaintext, for local
+	// development or behind a TLS terminating proxy.
+	var (
+		serverCreds []grpc.ServerOption
+		gatewayOpts []grpc.DialOption
+		httpTLS     *tls.Config
+	)
+	if cfg.TLSCert != "" {
+		tlsReloader, err := mtls.New(ctx, mtls.Options{
+			CertFile:          cfg.TLSCert,
+			KeyFile:           cfg.TLSKey,
+			ClientCAFile:      cfg.TLSClientCA,
+			RequireClientCert: cfg.TLSClientAuth == config.ClientAuthRequire,
+			ReloadInterval:    cfg.TLSReload,
+			Logger:            zapLogger,
+		})
+		if err != nil {
+			log.Fatalf("Failed to load TLS certificate: %v", err)
+		}
+		serverCreds = append(serverCreds, grpc.Creds(credentials.NewTLS(tlsReloader.ServerConfig())))
+		gatewayOpts = append(gatewayOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsReloader.LoopbackConfig())))
+		// The HTTP listeners call the gRPC server in process or over the
+		// loopback, so they have to check client certificates themselves.
+		httpTLS = tlsReloader.ServerConfig()
+	}
+
+	s := grpc.NewServer(append(serverCreds,
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	)...)
 	proto.RegisterUserServiceServer(s, svc)
 
+	checker := health.NewChecker(pinger, zapLogger, cfg.HealthInterval, proto.UserService_ServiceDesc.ServiceName)
+	healthpb.RegisterHealthServer(s, checker.Server)
+	go checker.Run(ctx)
+
+	// Reflection and channelz go on the admin listener when one is
+	// configured, otherwise on the main server in DEV only.
+	var adminServer *grpc.Server
+	switch {
+	case cfg.AdminPort != 0:
+		adminLis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.AdminPort))
+		if err != nil {
+			log.Fatalf("failed to listen on admin port: %v", err)
+		}
+		adminServer = grpc.NewServer(serverCreds...)
+		admin.Register(adminServer, s)
+		go func() {
+			if err := adminServer.Serve(adminLis); err != nil {
+				log.Printf("Admin server stopped: %v", err)
+			}
+		}()
+		log.Printf("Admin services started on port %d", cfg.AdminPort)
+	case cfg.Environment == config.Development:
+		admin.Register(s, s)
+		log.Println("Admin services registered on the main server")
+	}
+
+	var httpServer *http.Server
+	if cfg.HTTPPort != 0 {
+		gw, err := gateway.New(ctx, fmt.Sprintf("localhost:%d", cfg.Port), gatewayOpts...)
+		if err != nil {
+			log.Fatalf("Failed to create HTTP gateway: %v", err)
+		}
+		defer gw.Close()
+
+		httpServer = &http.Server{
+			Addr:              fmt.Sprintf(":%d", cfg.HTTPPort),
+			Handler:           gw,
+			ReadHeaderTimeout: 10 * time.Second,
+			TLSConfig:         httpTLS,
+		}
+		go func() {
+			if err := listenAndServe(httpServer); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("HTTP gateway failed: %v", err)
+			}
+		}()
+		log.Printf("HTTP gateway started on port %d", cfg.HTTPPort)
+	}
+
+	var webServer *http.Server
+	if cfg.WebPort != 0 {
+		handler, err := web.NewHandler(s, cfg.CORSOrigins)
+		if err != nil {
+			log.Fatalf("Failed to create Connect/gRPC-Web handler: %v", err)
+		}
+		webServer = web.NewServer(fmt.Sprintf(":%d", cfg.WebPort), handler, httpTLS)
+		go func() {
+			if err := listenAndServe(webServer); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Connect/gRPC-Web server failed: %v", err)
+			}
+		}()
+		log.Printf("Connect/gRPC-Web server started on port %d", cfg.WebPort)
+	}
+
+	var metricsServer *http.Server
+	if cfg.MetricsPort != 0 {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metricsHandler)
+		metricsServer = &http.Server{
+			Addr:              fmt.Sprintf(":%d", cfg.MetricsPort),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Metrics server failed: %v", err)
+			}
+		}()
+		log.Printf("Metrics served on port %d at /metrics", cfg.MetricsPort)
+	}
+
+	// The log level is changed on its own listener, which can be kept off
+	// the network metrics are scraped from, and requires a client
+	// certificate like the other listeners when TLS is on.
+	var adminHTTPServer *http.Server
+	if cfg.AdminHTTPPort != 0 {
+		mux := http.NewServeMux()
+		// GET returns the log level as JSON, PUT {"level":"debug"} sets it.
+		mux.Handle("/loglevel", logLevel)
+		adminHTTPServer = &http.Server{
+			Addr:              fmt.Sprintf(":%d", cfg.AdminHTTPPort),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+			TLSConfig:         httpTLS,
+		}
+		go func() {
+			if err := listenAndServe(adminHTTPServer); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Admin HTTP server failed: %v", err)
+			}
+		}()
+		log.Printf("Log level served on port %d at /loglevel", cfg.AdminHTTPPort)
+	}
+
+	// main waits for the shutdown to finish, Serve returns as soon as
+	// GracefulStop is called.
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		<-ctx.Done()
 		log.Println("Shutting down...")
+		// Keep serving while NOT_SERVING is reported, so that probes and
+		// load balancers notice before the servers stop taking calls.
+		checker.Shutdown()
+		if cfg.DrainDelay > 0 {
+			log.Printf("Reporting NOT_SERVING for %v before draining", cfg.DrainDelay)
+			time.Sleep(cfg.DrainDelay)
+		}
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer shutdownCancel()
-		if err := telemetry.Shutdown(shutdownCtx, tp); err != nil {
-			log.Printf("Error shutting down tracer: %v", err)
+		if metricsServer != nil {
+			if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+				log.Printf("Error shutting down metrics server: %v", err)
+			}
+		}
+		if adminHTTPServer != nil {
+			if err := adminHTTPServer.Shutdown(shutdownCtx); err != nil {
+				log.Printf("Error shutting down admin HTTP server: %v", err)
+			}
+		}
+		if httpServer != nil {
+			if err := httpServer.Shutdown(shutdownCtx); err != nil {
+				log.Printf("Error shutting down HTTP gateway: %v", err)
+			}
+		}
+		if webServer != nil {
+			if err := webServer.Shutdown(shutdownCtx); err != nil {
+				log.Printf("Error shutting down Connect/gRPC-Web server: %v", err)
+			}
+		}
+		if adminServer != nil {
+			adminServer.Stop()
 		}
 		s.GracefulStop()
+
+		// Telemetry goes last, so the spans, logs and metrics of the calls
+		// drained above are exported.
+		telemetryCtx, telemetryCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer telemetryCancel()
+		if err := telemetry.Shutdown(telemetryCtx, tp); err != nil {
+			log.Printf("Error shutting down tracer: %v", err)
+		}
+		if lp != nil {
+			if err := lp.Shutdown(telemetryCtx); err != nil {
+				log.Printf("Error shutting down logger provider: %v", err)
+			}
+		}
+		if mp != nil {
+			if err := mp.Shutdown(telemetryCtx); err != nil {
+				log.Printf("Error shutting down meter provider: %v", err)
+			}
+		}
 	}()
 
-	log.Printf("Server started on port %d with tracing enabled", cfg.Port)
+	log.Printf("Server started on port %d, traces exported via %s, logs via %s", cfg.Port, cfg.TracesExporter, cfg.LogsExporter)
 	if err := s.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
+	<-stopped
+}
+
+// listenAndServe serves TLS when srv has a TLS config, with the certificate
+// the config provides.
+func listenAndServe(srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
+
+// groupLookup resolves the group of a user for the group leader rule. Unknown
+// users have no group, so the rule simply does not match for them.
+func groupLookup(repo repository.UserRepository) auth.GroupLookup {
+	return func(ctx context.Context, userUUID string) (string, error) {
+		id, err := uuid.Parse(userUUID)
+		if err != nil {
+			return "", nil
+		}
+		details, err := repo.GetUserDetails(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return details.GroupCode, nil
+	}
+}
+
+func newPool(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
+	pgconfig, err := pgxpool.ParseConfig(cfg.DBConn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DB connection string: %w", err)
+	}
+
+	pgconfig.ConnConfig.Tracer = otelpgx.NewTracer(
+		otelpgx.WithTrimSQLInSpanName(),
+	)
+
+	return pgxpool.NewWithConfig(ctx, pgconfig)
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"labgrab/user_service/internal/repository/migrations"
+	"labgrab/user_service/internal/repository/schemacheck"
+	"labgrab/user_service/pkg/config"
+)
+
+const migrateUsage = "usage: user_service migrate up|down|status"
+
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+	if cfg.Storage != config.StoragePostgres {
+		return fmt.Errorf("migrations need STORAGE=%s, got %s", config.StoragePostgres, cfg.Storage)
+	}
+
+	conn, err := newPool(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	migrator, err := migrations.New(conn)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.Printf("Applied migration %d_%s", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			log.Println("Database is up to date")
+		}
+		return err
+	case "down":
+		m, ok, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			log.Println("No migrations to roll back")
+			return nil
+		}
+		log.Printf("Rolled back migration %d_%s", m.Version, m.Name)
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT\tCHECKSUM")
+		for _, s := range statuses {
+			appliedAt, checksum := "pending", "ok"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Modified {
+				checksum = "MODIFIED"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, appliedAt, checksum)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// prepareSchema applies pending migrations when AUTO_MIGRATE is set and
+// otherwise only warns about them.
+func prepareSchema(ctx context.Context, cfg *config.Config, conn *pgxpool.Pool) error {
+	migrator, err := migrations.New(conn)
+	if err != nil {
+		return err
+	}
+
+	if !cfg.AutoMigrate {
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if pending {
+			log.Println("Database has pending migrations, run `user_service migrate up` or set AUTO_MIGRATE=true")
+		}
+		return nil
+	}
+
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		log.Printf("Applied migration %d_%s", m.Version, m.Name)
+	}
+	return err
+}
+
+// checkSchema compares the live tables with the expected schema. Differences
+// are logged, and with SCHEMA_CHECK=strict they also stop the startup.
+func checkSchema(ctx context.Context, cfg *config.Config, conn *pgxpool.Pool) error {
+	if cfg.SchemaCheck == config.SchemaCheckOff {
+		return nil
+	}
+
+	expected := schemacheck.Expected()
+	actual, err := schemacheck.Inspect(ctx, conn, expected)
+	if err != nil {
+		return err
+	}
+
+	diffs := schemacheck.Compare(expected, actual)
+	for _, d := range diffs {
+		log.Printf("Schema drift: %s", d)
+	}
+	if len(diffs) > 0 && cfg.SchemaCheck == config.SchemaCheckStrict {
+		return fmt.Errorf("found %d differences from the expected schema", len(diffs))
+	}
+	return nil
+}
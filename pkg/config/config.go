@@ -1,11 +1,21 @@
 package config
 
 import (
+	"encoding"
 	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"reflect"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"go.uber.org/zap/zapcore"
+
+	"labgrab/user_service/pkg/redact"
 )
 
 type Environment string
@@ -15,54 +25,361 @@ const (
 	Production  Environment = "PROD"
 )
 
+type Storage string
+
+const (
+	StoragePostgres Storage = "postgres"
+	StorageMemory   Storage = "memory"
+)
+
+type SchemaCheck string
+
+const (
+	SchemaCheckOff    SchemaCheck = "off"
+	SchemaCheckWarn   SchemaCheck = "warn"
+	SchemaCheckStrict SchemaCheck = "strict"
+)
+
+type Exporter string
+
+const (
+	ExporterOTLP    Exporter = "otlp"
+	ExporterConsole Exporter = "console"
+	ExporterNone    Exporter = "none"
+)
+
+type OTLPProtocol string
+
+const (
+	OTLPProtocolGRPC OTLPProtocol = "grpc"
+	OTLPProtocolHTTP OTLPProtocol = "http/protobuf"
+)
+
+type LogOutput string
+
+const (
+	LogOutputStdout LogOutput = "stdout"
+	LogOutputFile   LogOutput = "file"
+	LogOutputBoth   LogOutput = "both"
+)
+
+type ClientAuth string
+
+const (
+	ClientAuthOptional ClientAuth = "optional"
+	ClientAuthRequire  ClientAuth = "require"
+)
+
+// Config is loaded from the environment variables named in the env tags of
+// its fields, or from the config file keys of the same names in lower case.
+// A setting found in neither takes the value of the default tag, and is
+// reported missing when it is marked required. Secret settings are masked
+// by Print.
 type Config struct {
-	Port           int         `env:"PORT,required"`
-	DBConn         string      `env:"DB_CONNECT"`
-	ServiceName    string      `env:"SERVICE_NAME"`
-	JaegerEndpoint string      `env:"JAEGER_ENDPOINT"`
-	Environment    Environment `env:"ENVIRONMENT"`
+	Port        int    `env:"PORT,required"`
+	DBConn      string `env:"DB_CONNECT,secret"`
+	ServiceName string `env:"SERVICE_NAME,required"`
+	// The OTLP endpoint, headers and timeouts are read by the exporters
+	// from the standard OTEL_EXPORTER_OTLP_* variables, and the sampler by
+	// the SDK from OTEL_TRACES_SAMPLER and OTEL_TRACES_SAMPLER_ARG. The
+	// protocols of traces and logs fall back to OTLPProtocol.
+	TracesExporter Exporter      `env:"OTEL_TRACES_EXPORTER" default:"otlp"`
+	OTLPProtocol   OTLPProtocol  `env:"OTEL_EXPORTER_OTLP_PROTOCOL" default:"grpc"`
+	TracesProtocol OTLPProtocol  `env:"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"`
+	LogsExporter   Exporter      `env:"OTEL_LOGS_EXPORTER" default:"otlp"`
+	LogsProtocol   OTLPProtocol  `env:"OTEL_EXPORTER_OTLP_LOGS_PROTOCOL"`
+	Environment    Environment   `env:"ENVIRONMENT,required"`
+	LogLevel       zapcore.Level `env:"LOG_LEVEL" default:"info"`
+	LogOutput      LogOutput     `env:"LOG_OUTPUT" default:"file"`
+	LogFile        string        `env:"LOG_FILE" default:"logs/user-service.log"`
+	LogMaxSize     int           `env:"LOG_MAX_SIZE_MB" default:"100"`
+	// LogMaxBackups is the number of rotated files kept, zero keeps them
+	// all. LogMaxAge is the number of days they are kept, zero keeps them
+	// regardless of age.
+	LogMaxBackups  int           `env:"LOG_MAX_BACKUPS" default:"3"`
+	LogMaxAge      int           `env:"LOG_MAX_AGE_DAYS" default:"28"`
+	LogCompress    bool          `env:"LOG_COMPRESS" default:"true"`
+	Redaction      redact.Mode   `env:"PII_REDACTION" default:"mask"`
+	PIIHashKey     string        `env:"PII_HASH_KEY,secret"`
+	Storage        Storage       `env:"STORAGE" default:"postgres"`
+	AutoMigrate    bool          `env:"AUTO_MIGRATE" default:"false"`
+	SchemaCheck    SchemaCheck   `env:"SCHEMA_CHECK" default:"warn"`
+	HealthInterval time.Duration `env:"HEALTH_CHECK_INTERVAL" default:"5s"`
+	DrainDelay     time.Duration `env:"SHUTDOWN_DRAIN_DELAY" default:"5s"`
+	AdminPort      int           `env:"ADMIN_PORT"`
+	AdminHTTPPort  int           `env:"ADMIN_HTTP_PORT"`
+	HTTPPort       int           `env:"HTTP_PORT"`
+	WebPort        int           `env:"WEB_PORT"`
+	MetricsPort    int           `env:"METRICS_PORT"`
+	CORSOrigins    []string      `env:"CORS_ALLOWED_ORIGINS"`
+	AuthJWKS       string        `env:"AUTH_JWKS"`
+	AuthIssuer     string        `env:"AUTH_ISSUER"`
+	AuthAudience   string        `env:"AUTH_AUDIENCE"`
+	AuthRefresh    time.Duration `env:"AUTH_JWKS_REFRESH" default:"5m"`
+	AuthPolicy     string        `env:"AUTH_POLICY"`
+	TLSCert        string        `env:"TLS_CERT_FILE"`
+	TLSKey         string        `env:"TLS_KEY_FILE"`
+	TLSClientCA    string        `env:"TLS_CLIENT_CA_FILE"`
+	TLSClientAuth  ClientAuth    `env:"TLS_CLIENT_AUTH" default:"require"`
+	TLSReload      time.Duration `env:"TLS_RELOAD_INTERVAL" default:"30s"`
+	RateLimits     string        `env:"RATE_LIMITS"`
 }
 
-func Load() (*Config, error) {
-	err := godotenv.Load()
+// Load reads the configuration from the environment, after adding the
+// variables of a .env file in the working directory if there is one, and
+// from the YAML or TOML file at path unless path is empty. Environment
+// variables take precedence over the file. All missing and invalid
+// settings are reported together.
+func Load(path string) (*Config, error) {
+	lookup, err := sources(path)
 	if err != nil {
 		return nil, err
 	}
-
-	portStr := os.Getenv("PORT")
-	if portStr == "" {
-		return nil, errors.New("PORT environment variable not set")
+	cfg := &Config{}
+	if err := load(cfg, lookup); err != nil {
+		return nil, err
 	}
-	port, err := strconv.Atoi(portStr)
+	return cfg, nil
+}
+
+// LoadDatabase reads only STORAGE and DB_CONNECT, from the same sources as
+// Load, so that migrations can run without the settings of the server.
+func LoadDatabase(path string) (*Config, error) {
+	lookup, err := sources(path)
 	if err != nil {
 		return nil, err
 	}
+	cfg := &Config{}
+	missing, errs := loadFields(cfg, lookup, "STORAGE", "DB_CONNECT")
+	if cfg.DBConn == "" && cfg.Storage == StoragePostgres {
+		missing = append(missing, "DB_CONNECT")
+	}
+	if len(missing) > 0 {
+		errs = append([]error{fmt.Errorf("missing required settings: %s", strings.Join(missing, ", "))}, errs...)
+	}
+	if err := oneOf("STORAGE", cfg.Storage, StoragePostgres, StorageMemory); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// sources returns the lookup of a setting in the environment, with the .env
+// file added, and then in the config file at path unless path is empty.
+func sources(path string) (func(string) (string, bool), error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read .env: %w", err)
+	}
+	var file map[string]string
+	if path != "" {
+		var err error
+		if file, err = readFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			return value, true
+		}
+		value, ok := file[name]
+		return value, ok
+	}, nil
+}
 
-	dbConn := os.Getenv("DB_CONNECT")
-	if dbConn == "" {
-		return nil, errors.New("DB_CONNECT environment variable not set")
+// envTag returns the variable name of field and whether it is required or
+// secret. ok is false for fields without an env tag.
+func envTag(field reflect.StructField) (name string, required, secret, ok bool) {
+	tag, ok := field.Tag.Lookup("env")
+	if !ok {
+		return "", false, false, false
 	}
+	name, opts, _ := strings.Cut(tag, ",")
+	for _, opt := range strings.Split(opts, ",") {
+		switch opt {
+		case "required":
+			required = true
+		case "secret":
+			secret = true
+		}
+	}
+	return name, required, secret, true
+}
 
-	serviceName := os.Getenv("SERVICE_NAME")
-	if serviceName == "" {
-		return nil, errors.New("SERVICE_NAME environment variable not set")
+func load(cfg *Config, lookup func(string) (string, bool)) error {
+	missing, errs := loadFields(cfg, lookup)
+
+	// Settings that are only required by the value of others.
+	if cfg.DBConn == "" && cfg.Storage == StoragePostgres {
+		missing = append(missing, "DB_CONNECT")
+	}
+	if cfg.AuthJWKS == "" && cfg.Environment == Production {
+		missing = append(missing, "AUTH_JWKS")
+	}
+	if len(missing) > 0 {
+		errs = append([]error{fmt.Errorf("missing required settings: %s", strings.Join(missing, ", "))}, errs...)
 	}
 
-	jaegerEndpoint := os.Getenv("JAEGER_ENDPOINT")
-	if jaegerEndpoint == "" {
-		return nil, errors.New("JAEGER_ENDPOINT environment variable not set")
+	if cfg.TracesProtocol == "" {
+		cfg.TracesProtocol = cfg.OTLPProtocol
 	}
+	if cfg.LogsProtocol == "" {
+		cfg.LogsProtocol = cfg.OTLPProtocol
+	}
+	errs = append(errs, cfg.validate()...)
+	return errors.Join(errs...)
+}
 
-	environment := Environment(os.Getenv("ENVIRONMENT"))
-	if environment == "" {
-		return nil, errors.New("ENVIRONMENT environment variable not set")
+// loadFields sets the fields of cfg named in only, or all of them when
+// only is empty, and returns the required settings that are missing.
+func loadFields(cfg *Config, lookup func(string) (string, bool), only ...string) (missing []string, errs []error) {
+	v := reflect.ValueOf(cfg).Elem()
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		name, required, _, ok := envTag(field)
+		if !ok || len(only) > 0 && !slices.Contains(only, name) {
+			continue
+		}
+		value, _ := lookup(name)
+		if value == "" {
+			if required {
+				missing = append(missing, name)
+				continue
+			}
+			if value, ok = field.Tag.Lookup("default"); !ok {
+				continue
+			}
+		}
+		if err := setField(v.Field(i), value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
 	}
+	return missing, errs
+}
 
-	return &Config{
-		Port:           port,
-		DBConn:         dbConn,
-		ServiceName:    serviceName,
-		JaegerEndpoint: jaegerEndpoint,
-		Environment:    environment,
-	}, nil
+var durationType = reflect.TypeFor[time.Duration]()
+
+func setField(field reflect.Value, value string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items).Convert(field.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+func (c *Config) validate() []error {
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	check(oneOf("ENVIRONMENT", c.Environment, Development, Production))
+	check(oneOf("OTEL_TRACES_EXPORTER", c.TracesExporter, ExporterOTLP, ExporterConsole, ExporterNone))
+	check(oneOf("OTEL_LOGS_EXPORTER", c.LogsExporter, ExporterOTLP, ExporterConsole, ExporterNone))
+	check(oneOf("OTEL_EXPORTER_OTLP_PROTOCOL", c.OTLPProtocol, OTLPProtocolGRPC, OTLPProtocolHTTP))
+	check(oneOf("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", c.TracesProtocol, OTLPProtocolGRPC, OTLPProtocolHTTP))
+	check(oneOf("OTEL_EXPORTER_OTLP_LOGS_PROTOCOL", c.LogsProtocol, OTLPProtocolGRPC, OTLPProtocolHTTP))
+	check(oneOf("LOG_OUTPUT", c.LogOutput, LogOutputStdout, LogOutputFile, LogOutputBoth))
+	check(oneOf("PII_REDACTION", c.Redaction, redact.Mask, redact.Hash, redact.Drop))
+	check(oneOf("STORAGE", c.Storage, StoragePostgres, StorageMemory))
+	check(oneOf("SCHEMA_CHECK", c.SchemaCheck, SchemaCheckOff, SchemaCheckWarn, SchemaCheckStrict))
+	check(oneOf("TLS_CLIENT_AUTH", c.TLSClientAuth, ClientAuthOptional, ClientAuthRequire))
+
+	if c.LogMaxSize <= 0 {
+		errs = append(errs, errors.New("LOG_MAX_SIZE_MB must be positive"))
+	}
+	if c.LogMaxBackups < 0 {
+		errs = append(errs, errors.New("LOG_MAX_BACKUPS must not be negative"))
+	}
+	if c.LogMaxAge < 0 {
+		errs = append(errs, errors.New("LOG_MAX_AGE_DAYS must not be negative"))
+	}
+	if c.HealthInterval <= 0 {
+		errs = append(errs, errors.New("HEALTH_CHECK_INTERVAL must be positive"))
+	}
+	if c.DrainDelay < 0 {
+		errs = append(errs, errors.New("SHUTDOWN_DRAIN_DELAY must not be negative"))
+	}
+	if c.AuthRefresh <= 0 {
+		errs = append(errs, errors.New("AUTH_JWKS_REFRESH must be positive"))
+	}
+	if c.TLSReload <= 0 {
+		errs = append(errs, errors.New("TLS_RELOAD_INTERVAL must be positive"))
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+	if c.TLSClientCA != "" && c.TLSCert == "" {
+		errs = append(errs, errors.New("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE"))
+	}
+	// Client certificates are only requested with a CA to verify them.
+	if c.TLSCert != "" && c.TLSClientCA == "" && c.TLSClientAuth == ClientAuthRequire {
+		errs = append(errs, errors.New("TLS_CLIENT_AUTH=require needs TLS_CLIENT_CA_FILE, set TLS_CLIENT_AUTH=optional to serve TLS without client certificates"))
+	}
+	return errs
+}
+
+// oneOf checks value against the allowed values. An empty value was
+// already reported missing.
+func oneOf[T ~string](name string, value T, allowed ...T) error {
+	if value == "" {
+		return nil
+	}
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	quoted := make([]string, len(allowed))
+	for i, a := range allowed {
+		quoted[i] = strconv.Quote(string(a))
+	}
+	list := strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+	return fmt.Errorf("%s must be %s, got %q", name, list, value)
 }
@@ -0,0 +1,187 @@
+package config_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"labgrab/user_service/pkg/config"
+)
+
+// setEnv unsets every variable the config reads until the test ends, then
+// sets vars. It runs in an empty directory, so no .env file is picked up.
+func setEnv(t *testing.T, vars map[string]string) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	typ := reflect.TypeFor[config.Config]()
+	for i := range typ.NumField() {
+		if tag, ok := typ.Field(i).Tag.Lookup("env"); ok {
+			name, _, _ := strings.Cut(tag, ",")
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+	for k, v := range vars {
+		t.Setenv(k, v)
+	}
+}
+
+var minimal = map[string]string{
+	"PORT":         "50051",
+	"SERVICE_NAME": "user-service",
+	"ENVIRONMENT":  "DEV",
+	"STORAGE":      "memory",
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setEnv(t, minimal)
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Port != 50051 {
+		t.Errorf("Port = %d, want 50051", cfg.Port)
+	}
+	if cfg.HealthInterval != 5*time.Second {
+		t.Errorf("HealthInterval = %v, want 5s", cfg.HealthInterval)
+	}
+	if !cfg.LogCompress {
+		t.Error("LogCompress = false, want true")
+	}
+	if cfg.LogLevel != zapcore.InfoLevel {
+		t.Errorf("LogLevel = %v, want info", cfg.LogLevel)
+	}
+	if cfg.TracesProtocol != config.OTLPProtocolGRPC {
+		t.Errorf("TracesProtocol = %q, want %q", cfg.TracesProtocol, config.OTLPProtocolGRPC)
+	}
+	if cfg.CORSOrigins != nil {
+		t.Errorf("CORSOrigins = %q, want none", cfg.CORSOrigins)
+	}
+}
+
+func TestLoadValues(t *testing.T) {
+	vars := map[string]string{
+		"AUTO_MIGRATE":                     "true",
+		"TLS_RELOAD_INTERVAL":              "1m",
+		"LOG_LEVEL":                        "debug",
+		"CORS_ALLOWED_ORIGINS":             " https://a.example, ,https://b.example",
+		"OTEL_EXPORTER_OTLP_PROTOCOL":      "http/protobuf",
+		"OTEL_EXPORTER_OTLP_LOGS_PROTOCOL": "grpc",
+	}
+	for k, v := range minimal {
+		vars[k] = v
+	}
+	setEnv(t, vars)
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if !cfg.AutoMigrate {
+		t.Error("AutoMigrate = false, want true")
+	}
+	if cfg.TLSReload != time.Minute {
+		t.Errorf("TLSReload = %v, want 1m", cfg.TLSReload)
+	}
+	if cfg.LogLevel != zapcore.DebugLevel {
+		t.Errorf("LogLevel = %v, want debug", cfg.LogLevel)
+	}
+	if want := []string{"https://a.example", "https://b.example"}; !reflect.DeepEqual(cfg.CORSOrigins, want) {
+		t.Errorf("CORSOrigins = %q, want %q", cfg.CORSOrigins, want)
+	}
+	if cfg.TracesProtocol != config.OTLPProtocolHTTP {
+		t.Errorf("TracesProtocol = %q, want %q", cfg.TracesProtocol, config.OTLPProtocolHTTP)
+	}
+	if cfg.LogsProtocol != config.OTLPProtocolGRPC {
+		t.Errorf("LogsProtocol = %q, want %q", cfg.LogsProtocol, config.OTLPProtocolGRPC)
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	setEnv(t, nil)
+	dotEnv := "PORT=50051\nSERVICE_NAME=user-service\nENVIRONMENT=DEV\nSTORAGE=memory\n"
+	if err := os.WriteFile(".env", []byte(dotEnv), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.ServiceName != "user-service" {
+		t.Errorf("ServiceName = %q, want %q", cfg.ServiceName, "user-service")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+		want []string
+	}{
+		{
+			name: "all missing at once",
+			vars: map[string]string{"ENVIRONMENT": "PROD"},
+			want: []string{"missing required settings: PORT, SERVICE_NAME, DB_CONNECT, AUTH_JWKS"},
+		},
+		{
+			name: "invalid values",
+			vars: map[string]string{
+				"PORT":                  "port",
+				"SERVICE_NAME":          "user-service",
+				"ENVIRONMENT":           "STAGING",
+				"STORAGE":               "memory",
+				"HEALTH_CHECK_INTERVAL": "-1s",
+				"LOG_LEVEL":             "loud",
+				"TLS_CERT_FILE":         "cert.pem",
+			},
+			want: []string{
+				"PORT: strconv.Atoi",
+				`ENVIRONMENT must be "DEV" or "PROD", got "STAGING"`,
+				"HEALTH_CHECK_INTERVAL must be positive",
+				"LOG_LEVEL: ",
+				"TLS_CERT_FILE and TLS_KEY_FILE must be set together",
+				"TLS_CLIENT_AUTH=require needs TLS_CLIENT_CA_FILE",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.vars)
+			_, err := config.Load("")
+			if err == nil {
+				t.Fatal("Load succeeded, want an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Load() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadDatabase(t *testing.T) {
+	// A PROD server needs AUTH_JWKS and more, migrations only the database.
+	setEnv(t, map[string]string{
+		"ENVIRONMENT": "PROD",
+		"DB_CONNECT":  "postgres://user:secret@db/users",
+		"LOG_LEVEL":   "loud",
+	})
+	cfg, err := config.LoadDatabase("")
+	if err != nil {
+		t.Fatalf("LoadDatabase: %v", err)
+	}
+	if cfg.Storage != config.StoragePostgres || cfg.DBConn != "postgres://user:secret@db/users" {
+		t.Errorf("Storage, DBConn = %q, %q", cfg.Storage, cfg.DBConn)
+	}
+
+	setEnv(t, map[string]string{"ENVIRONMENT": "PROD"})
+	if _, err := config.LoadDatabase(""); err == nil || !strings.Contains(err.Error(), "DB_CONNECT") {
+		t.Errorf("LoadDatabase without DB_CONNECT: err = %v, want DB_CONNECT missing", err)
+	}
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"go.yaml.in/yaml/v3"
+)
+
+// readFile reads a YAML or TOML config file into values by variable name.
+// Lists are joined with commas, as they are written in the environment.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var raw map[string]any
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("config file must be .yaml, .yml or .toml, got %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	known := map[string]bool{}
+	typ := reflect.TypeFor[Config]()
+	for i := range typ.NumField() {
+		if name, _, _, ok := envTag(typ.Field(i)); ok {
+			known[name] = true
+		}
+	}
+
+	values := map[string]string{}
+	var errs []error
+	for key, value := range raw {
+		name := strings.ToUpper(key)
+		if !known[name] {
+			errs = append(errs, fmt.Errorf("config file: unknown key %q", key))
+			continue
+		}
+		switch v := value.(type) {
+		case nil:
+			values[name] = ""
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[name] = strings.Join(items, ",")
+		case map[string]any:
+			errs = append(errs, fmt.Errorf("config file: %s must be a value or a list", key))
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Print writes cfg to w as a YAML config file, with secrets masked.
+func Print(w io.Writer, cfg *Config) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	v := reflect.ValueOf(cfg).Elem()
+	for i := range v.NumField() {
+		name, _, secret, ok := envTag(v.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		var value any
+		switch field := v.Field(i).Interface().(type) {
+		case time.Duration:
+			value = field.String()
+		case encoding.TextMarshaler:
+			text, err := field.MarshalText()
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			value = string(text)
+		default:
+			value = field
+		}
+		if secret {
+			value = mask(fmt.Sprint(value))
+		}
+
+		valueNode := &yaml.Node{}
+		if err := valueNode.Encode(value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		doc.Content = append(doc.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: strings.ToLower(name)},
+			valueNode,
+		)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// dsnPassword matches the password of a key/value connection string, bare
+// or single-quoted with backslash escapes, as in password='a \'b'.
+var dsnPassword = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*(?:'|$)|\S+)`)
+
+// mask hides a secret. Only the password of a connection string is hidden,
+// the host and database are useful when checking the config.
+func mask(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if u, err := url.Parse(secret); err == nil && u.Scheme != "" && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return u.Redacted()
+		}
+	}
+	if dsnPassword.MatchString(secret) {
+		return dsnPassword.ReplaceAllString(secret, "${1}xxxxx")
+	}
+	// Anything else, including a connection string that could not be
+	// parsed, is hidden as a whole.
+	return "xxxxx"
+}
@@ -0,0 +1,169 @@
+package config_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"labgrab/user_service/pkg/config"
+)
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"yaml", "config.yaml", `
+port: 50051
+service_name: from-file
+environment: DEV
+storage: memory
+health_check_interval: 10s
+cors_allowed_origins: [https://a.example, https://b.example]
+`},
+		{"toml", "config.toml", `
+port = 50051
+service_name = "from-file"
+environment = "DEV"
+storage = "memory"
+health_check_interval = "10s"
+cors_allowed_origins = ["https://a.example", "https://b.example"]
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, map[string]string{"PORT": "6000"})
+			if err := os.WriteFile(tt.file, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+
+			cfg, err := config.Load(tt.file)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Port != 6000 {
+				t.Errorf("Port = %d, want the environment's 6000", cfg.Port)
+			}
+			if cfg.ServiceName != "from-file" {
+				t.Errorf("ServiceName = %q, want %q", cfg.ServiceName, "from-file")
+			}
+			if cfg.HealthInterval != 10*time.Second {
+				t.Errorf("HealthInterval = %v, want 10s", cfg.HealthInterval)
+			}
+			if want := []string{"https://a.example", "https://b.example"}; !reflect.DeepEqual(cfg.CORSOrigins, want) {
+				t.Errorf("CORSOrigins = %q, want %q", cfg.CORSOrigins, want)
+			}
+		})
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{"unknown key", "config.yaml", "port: 1\nprot: 2\n", `unknown key "prot"`},
+		{"nested value", "config.yaml", "port:\n  grpc: 1\n", "port must be a value or a list"},
+		{"unsupported format", "config.json", "{}", `got ".json"`},
+		{"invalid yaml", "config.yaml", "port: [", "failed to parse config file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, nil)
+			if err := os.WriteFile(tt.file, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			_, err := config.Load(tt.file)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	vars := map[string]string{
+		"DB_CONNECT":   "postgres://app:hunter2@db:5432/users",
+		"PII_HASH_KEY": "pepper",
+	}
+	for k, v := range minimal {
+		vars[k] = v
+	}
+	setEnv(t, vars)
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	var out strings.Builder
+	if err := config.Print(&out, cfg); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+	for _, secret := range []string{"hunter2", "pepper"} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("Print() output contains %q:\n%s", secret, out.String())
+		}
+	}
+	for _, want := range []string{
+		"db_connect: postgres://app:xxxxx@db:5432/users\n",
+		"health_check_interval: 5s\n",
+		"log_level: info\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Print() output does not contain %q:\n%s", want, out.String())
+		}
+	}
+
+	// The output is a config file that loads to the same config, except
+	// for the secrets.
+	setEnv(t, nil)
+	if err := os.WriteFile("printed.yaml", []byte(out.String()), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	reloaded, err := config.Load("printed.yaml")
+	if err != nil {
+		t.Fatalf("Load(printed): %v", err)
+	}
+	reloaded.DBConn, reloaded.PIIHashKey = cfg.DBConn, cfg.PIIHashKey
+	if !reflect.DeepEqual(reloaded, cfg) {
+		t.Errorf("reloaded config = %+v, want %+v", reloaded, cfg)
+	}
+}
+
+func TestPrintMasksPasswords(t *testing.T) {
+	tests := []struct {
+		dsn    string
+		want   string
+		secret string
+	}{
+		{"host=db password=hunter2 dbname=users", "host=db password=xxxxx dbname=users", "hunter2"},
+		{"host=db password='hunter 2' dbname=users", "host=db password=xxxxx dbname=users", "hunter"},
+		{`host=db password = 'it\'s 2' dbname=users`, "host=db password = xxxxx dbname=users", "s 2"},
+		{"host=db password='hunter 2", "host=db password=xxxxx", "hunter"},
+		{"postgres://app:hunter2@db/users", "postgres://app:xxxxx@db/users", "hunter2"},
+		{"not a dsn hunter2", "xxxxx", "hunter2"},
+	}
+	for _, tt := range tests {
+		vars := map[string]string{"DB_CONNECT": tt.dsn}
+		for k, v := range minimal {
+			vars[k] = v
+		}
+		setEnv(t, vars)
+		cfg, err := config.Load("")
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		var out strings.Builder
+		if err := config.Print(&out, cfg); err != nil {
+			t.Fatalf("Print: %v", err)
+		}
+		if strings.Contains(out.String(), tt.secret) || !strings.Contains(out.String(), tt.want) {
+			t.Errorf("Print(%q) db_connect is not %q:\n%s", tt.dsn, tt.want, out.String())
+		}
+	}
+}
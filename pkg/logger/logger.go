@@ -2,38 +2,114 @@ package logger
 
 import (
 	"context"
+	"os"
+	"os/signal"
 
 	"github.com/natefinch/lumberjack"
+	"go.opentelemetry.io/contrib/bridges/otelzap"
+	"go.opentelemetry.io/otel/log"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"labgrab/user_service/pkg/redact"
 )
 
 type Options struct {
+	// Level is shared by every output, changing it takes effect at once.
+	Level zap.AtomicLevel
+	// Stdout writes records to standard output, in the console format when
+	// Development is set and as JSON otherwise.
+	Stdout      bool
+	Development bool
+	// Path is the file records are written to as JSON, rotated by size.
+	// Nothing is written to a file when it is empty.
 	Path       string
 	MaxSize    int
 	MaxBackups int
 	MaxAge     int
 	Compress   bool
+	// LoggerProvider receives every record in addition to the other
+	// outputs. Records are not bridged to OpenTelemetry when it is nil.
+	LoggerProvider log.LoggerProvider
+	// Redactor, when set, removes personal data from records before they
+	// reach any output.
+	Redactor *redact.Redactor
 }
 
 func Logger(options *Options) *zap.Logger {
-	log := &lumberjack.Logger{
-		Filename:   options.Path,
-		MaxSize:    options.MaxSize,
-		MaxBackups: options.MaxBackups,
-		MaxAge:     options.MaxAge,
-		Compress:   options.Compress,
+	var cores []zapcore.Core
+	if options.Stdout {
+		encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+		if options.Development {
+			encoderConfig := zap.NewDevelopmentEncoderConfig()
+			encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+			encoder = zapcore.NewConsoleEncoder(encoderConfig)
+		}
+		cores = append(cores, zapcore.NewCore(encoder, zapcore.Lock(os.Stdout), options.Level))
+	}
+	if options.Path != "" {
+		log := &lumberjack.Logger{
+			Filename:   options.Path,
+			MaxSize:    options.MaxSize,
+			MaxBackups: options.MaxBackups,
+			MaxAge:     options.MaxAge,
+			Compress:   options.Compress,
+		}
+		cores = append(cores, zapcore.NewCore(
+			zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+			zapcore.AddSync(log),
+			options.Level,
+		))
+	}
+	if options.LoggerProvider != nil {
+		var bridge zapcore.Core = otelzap.NewCore("labgrab/user_service", otelzap.WithLoggerProvider(options.LoggerProvider))
+		// This only fails when the provider already drops some of the
+		// levels enabled by Level.
+		if leveled, err := zapcore.NewIncreaseLevelCore(bridge, options.Level); err == nil {
+			bridge = leveled
+		}
+		cores = append(cores, bridge)
+	}
+	core := zapcore.NewTee(cores...)
+	if options.Redactor != nil {
+		core = redact.NewCore(core, options.Redactor)
 	}
-	writer := zapcore.AddSync(log)
-	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
-		writer,
-		zap.InfoLevel,
-	)
 	return zap.New(core)
 }
 
+// ToggleDebug switches level to debug when the process receives sig, and
+// back to the level it had before on the next one, until ctx is done.
+func ToggleDebug(ctx context.Context, level zap.AtomicLevel, logger *zap.Logger, sig os.Signal) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, sig)
+	go func() {
+		defer signal.Stop(signals)
+		previous := level.Level()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				next := zapcore.DebugLevel
+				if current := level.Level(); current == zapcore.DebugLevel {
+					next = previous
+				} else {
+					previous = current
+				}
+				level.SetLevel(next)
+				logger.Warn("Log level changed", zap.Stringer("level", next))
+			}
+		}
+	}()
+}
+
+// Context returns a field handing ctx to the OpenTelemetry bridge, which
+// takes the trace and span IDs of the record from it. Other cores skip it.
+func Context(ctx context.Context) zap.Field {
+	return zap.Field{Key: "context", Type: zapcore.SkipType, Interface: ctx}
+}
+
 func WithTraceContext(ctx context.Context, logger *zap.Logger) *zap.Logger {
 	spanCtx := trace.SpanContextFromContext(ctx)
 	if !spanCtx.IsValid() {
@@ -43,5 +119,6 @@ func WithTraceContext(ctx context.Context, logger *zap.Logger) *zap.Logger {
 	return logger.With(
 		zap.String("trace_id", spanCtx.TraceID().String()),
 		zap.String("span_id", spanCtx.SpanID().String()),
+		Context(ctx),
 	)
 }
@@ -0,0 +1,98 @@
+package logger_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"labgrab/user_service/pkg/logger"
+)
+
+type exporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *exporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *exporter) Shutdown(context.Context) error   { return nil }
+func (e *exporter) ForceFlush(context.Context) error { return nil }
+
+func TestLoggerBridge(t *testing.T) {
+	exp := &exporter{}
+	lp := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exp)))
+	zapLogger := logger.Logger(&logger.Options{
+		Level:          zap.NewAtomicLevelAt(zap.InfoLevel),
+		Path:           filepath.Join(t.TempDir(), "test.log"),
+		LoggerProvider: lp,
+	})
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "call")
+	defer span.End()
+	logger.WithTraceContext(ctx, zapLogger).Info("Request handled", zap.String("method", "GetUser"))
+	zapLogger.Debug("Not exported")
+
+	if len(exp.records) != 1 {
+		t.Fatalf("exported %d records, want 1", len(exp.records))
+	}
+	r := exp.records[0]
+	if got := r.Body().AsString(); got != "Request handled" {
+		t.Errorf("Body = %q, want %q", got, "Request handled")
+	}
+	if r.Severity() != log.SeverityInfo {
+		t.Errorf("Severity = %v, want %v", r.Severity(), log.SeverityInfo)
+	}
+	if got, want := r.TraceID(), span.SpanContext().TraceID(); got != want {
+		t.Errorf("TraceID = %v, want %v", got, want)
+	}
+	if got, want := r.SpanID(), span.SpanContext().SpanID(); got != want {
+		t.Errorf("SpanID = %v, want %v", got, want)
+	}
+	var method string
+	r.WalkAttributes(func(kv attribute.KeyValue) bool {
+		if kv.Key == "method" {
+			method = kv.Value.AsString()
+		}
+		return true
+	})
+	if method != "GetUser" {
+		t.Errorf("method attribute = %q, want %q", method, "GetUser")
+	}
+}
+
+func TestToggleDebug(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	level := zap.NewAtomicLevelAt(zap.WarnLevel)
+	logger.ToggleDebug(ctx, level, zap.NewNop(), syscall.SIGUSR1)
+
+	for _, want := range []zapcore.Level{zap.DebugLevel, zap.WarnLevel, zap.DebugLevel} {
+		if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatalf("Kill: %v", err)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for level.Level() != want && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if got := level.Level(); got != want {
+			t.Fatalf("level = %v, want %v", got, want)
+		}
+	}
+}
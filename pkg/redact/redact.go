@@ -0,0 +1,131 @@
+// Package redact removes phone numbers, email addresses and Telegram IDs
+// from log records and span attributes before they leave the process.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type Mode string
+
+const (
+	// Mask keeps a few characters, enough to tell values apart when
+	// debugging: "+7********67", "j***@example.com".
+	Mask Mode = "mask"
+	// Hash replaces values with a keyed hash, so the same value can be
+	// followed across records without being revealed.
+	Hash Mode = "hash"
+	// Drop removes fields and attributes holding personal data and replaces
+	// values found in text with a placeholder.
+	Drop Mode = "drop"
+)
+
+// dropped replaces values found in text in Drop mode.
+const dropped = "[redacted]"
+
+type kind int
+
+const (
+	phone kind = iota
+	email
+	telegramID
+)
+
+var (
+	emailRegexp = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// Phone numbers are stored in E.164, so a leading + keeps UUIDs and
+	// other numbers out.
+	phoneRegexp = regexp.MustCompile(`\+[1-9]\d{6,14}\b`)
+	// Telegram IDs are bare numbers, only those next to their name are
+	// recognized, as in "telegram_id=42" or "(telegram_id)=(42)".
+	telegramIDRegexp = regexp.MustCompile(`(?i)(telegram_?id\W{1,4})(\d+)`)
+)
+
+type Redactor struct {
+	mode Mode
+	key  []byte
+}
+
+// New returns a redactor in the given mode. In Hash mode values are hashed
+// with HMAC-SHA256 under key; without a key phone numbers and Telegram IDs
+// can be recovered by hashing every candidate, so set one in production.
+func New(mode Mode, key []byte) (*Redactor, error) {
+	switch mode {
+	case Mask, Hash, Drop:
+	default:
+		return nil, fmt.Errorf("unknown redaction mode %q", mode)
+	}
+	return &Redactor{mode: mode, key: key}, nil
+}
+
+// Sensitive reports whether a field or attribute named key holds personal
+// data as a whole, such as "phone_number", "user.email" or "telegram_id".
+func (r *Redactor) Sensitive(key string) bool {
+	_, ok := sensitiveKind(key)
+	return ok
+}
+
+func sensitiveKind(key string) (kind, bool) {
+	key = strings.ToLower(key)
+	switch {
+	case strings.Contains(key, "phone"):
+		return phone, true
+	case strings.Contains(key, "email"):
+		return email, true
+	case strings.Contains(key, "telegram"):
+		return telegramID, true
+	}
+	return 0, false
+}
+
+// Value redacts the whole value of a field or attribute named key, which
+// must be Sensitive. The second result is false when it is to be dropped.
+func (r *Redactor) Value(key, value string) (string, bool) {
+	if r.mode == Drop {
+		return "", false
+	}
+	k, _ := sensitiveKind(key)
+	return r.replace(k, value), true
+}
+
+// String redacts the phone numbers, email addresses and Telegram IDs found
+// in s, such as in error messages.
+func (r *Redactor) String(s string) string {
+	s = emailRegexp.ReplaceAllStringFunc(s, func(v string) string { return r.replace(email, v) })
+	s = phoneRegexp.ReplaceAllStringFunc(s, func(v string) string { return r.replace(phone, v) })
+	return telegramIDRegexp.ReplaceAllStringFunc(s, func(v string) string {
+		m := telegramIDRegexp.FindStringSubmatch(v)
+		return m[1] + r.replace(telegramID, m[2])
+	})
+}
+
+func (r *Redactor) replace(k kind, v string) string {
+	switch r.mode {
+	case Drop:
+		return dropped
+	case Hash:
+		mac := hmac.New(sha256.New, r.key)
+		mac.Write([]byte(v))
+		return "sha256:" + hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+
+	if k == email {
+		if local, domain, ok := strings.Cut(v, "@"); ok && local != "" {
+			return local[:1] + "***@" + domain
+		}
+	}
+	// Keep the + of a phone number and the last two digits.
+	keepStart, keepEnd := 0, 2
+	if strings.HasPrefix(v, "+") {
+		keepStart = 2
+	}
+	if len(v) <= keepStart+keepEnd {
+		return strings.Repeat("*", len(v))
+	}
+	return v[:keepStart] + strings.Repeat("*", len(v)-keepStart-keepEnd) + v[len(v)-keepEnd:]
+}
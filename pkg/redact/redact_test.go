@@ -0,0 +1,140 @@
+package redact_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"labgrab/user_service/pkg/redact"
+)
+
+func newRedactor(t *testing.T, mode redact.Mode) *redact.Redactor {
+	t.Helper()
+	r, err := redact.New(mode, []byte("key"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return r
+}
+
+func TestString(t *testing.T) {
+	const msg = `duplicate key (email)=(jane@example.com), phone +79991234567, telegram_id=123456789, user 123e4567-e89b-12d3-a456-426614174000`
+
+	tests := []struct {
+		mode redact.Mode
+		want string
+	}{
+		{redact.Mask, `duplicate key (email)=(j***@example.com), phone +7********67, telegram_id=*******89, user 123e4567-e89b-12d3-a456-426614174000`},
+		{redact.Drop, `duplicate key (email)=([redacted]), phone [redacted], telegram_id=[redacted], user 123e4567-e89b-12d3-a456-426614174000`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			if got := newRedactor(t, tt.mode).String(msg); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringHash(t *testing.T) {
+	r := newRedactor(t, redact.Hash)
+	first, second := r.String("jane@example.com"), r.String("jane@example.com")
+	if first != second {
+		t.Errorf("hashes differ: %q and %q", first, second)
+	}
+	if !strings.HasPrefix(first, "sha256:") || strings.Contains(first, "jane") {
+		t.Errorf("String() = %q, want a hash", first)
+	}
+	if other := r.String("john@example.com"); other == first {
+		t.Errorf("different values hash to %q", other)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := redact.New("erase", nil); err == nil {
+		t.Error("New accepted an unknown mode")
+	}
+}
+
+func TestCore(t *testing.T) {
+	tests := []struct {
+		mode        redact.Mode
+		wantPhone   any
+		wantDropped bool
+	}{
+		{redact.Mask, "+7********67", false},
+		{redact.Drop, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			obs, logs := observer.New(zap.DebugLevel)
+			log := zap.New(redact.NewCore(obs, newRedactor(t, tt.mode)))
+
+			dbErr := errors.New(`Key (email)=(jane@example.com) already exists`)
+			log.With(zap.String("phone_number", "+79991234567")).Error("Failed for jane@example.com",
+				zap.Int64("telegram_id", 123456789),
+				zap.Error(dbErr),
+				zap.String("method", "UpdateUserEmail"),
+			)
+
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("logged %d entries, want 1", len(entries))
+			}
+			if strings.Contains(entries[0].Message, "jane") {
+				t.Errorf("Message = %q, still contains the email", entries[0].Message)
+			}
+			fields := entries[0].ContextMap()
+			if got := fields["phone_number"]; got != tt.wantPhone {
+				t.Errorf("phone_number = %v, want %v", got, tt.wantPhone)
+			}
+			if _, ok := fields["telegram_id"]; ok == tt.wantDropped {
+				t.Errorf("telegram_id present = %v, want %v", ok, !tt.wantDropped)
+			}
+			if got := fields["error"]; strings.Contains(got.(string), "jane") {
+				t.Errorf("error = %q, still contains the email", got)
+			}
+			if got := fields["method"]; got != "UpdateUserEmail" {
+				t.Errorf("method = %v, want UpdateUserEmail", got)
+			}
+		})
+	}
+}
+
+func TestSpanExporter(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(redact.NewSpanExporter(exp, newRedactor(t, redact.Mask))))
+	_, span := tp.Tracer("test").Start(context.Background(), "call")
+	span.SetAttributes(
+		attribute.String("user.uuid", "123e4567-e89b-12d3-a456-426614174000"),
+		attribute.Int64("user.telegram_id", 123456789),
+	)
+	span.RecordError(errors.New("no user with email jane@example.com"))
+	span.End()
+
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	want := map[attribute.Key]string{
+		"user.uuid":        "123e4567-e89b-12d3-a456-426614174000",
+		"user.telegram_id": "*******89",
+	}
+	for _, kv := range spans[0].Attributes {
+		if kv.Value.Emit() != want[kv.Key] {
+			t.Errorf("%s = %q, want %q", kv.Key, kv.Value.Emit(), want[kv.Key])
+		}
+	}
+	for _, kv := range spans[0].Events[0].Attributes {
+		if strings.Contains(kv.Value.Emit(), "jane") {
+			t.Errorf("event attribute %s = %q, still contains the email", kv.Key, kv.Value.Emit())
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package redact
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type spanExporter struct {
+	sdktrace.SpanExporter
+	r *Redactor
+}
+
+// NewSpanExporter redacts the attributes, event attributes and status
+// descriptions of spans before exp exports them. Recorded errors are
+// events, so their messages are covered as well.
+func NewSpanExporter(exp sdktrace.SpanExporter, r *Redactor) sdktrace.SpanExporter {
+	return &spanExporter{SpanExporter: exp, r: r}
+}
+
+func (e *spanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	redacted := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, s := range spans {
+		redacted[i] = &span{ReadOnlySpan: s, r: e.r}
+	}
+	return e.SpanExporter.ExportSpans(ctx, redacted)
+}
+
+type span struct {
+	sdktrace.ReadOnlySpan
+	r *Redactor
+}
+
+func (s *span) Attributes() []attribute.KeyValue {
+	return s.r.Attributes(s.ReadOnlySpan.Attributes())
+}
+
+func (s *span) Events() []sdktrace.Event {
+	events := s.ReadOnlySpan.Events()
+	redacted := make([]sdktrace.Event, len(events))
+	for i, e := range events {
+		e.Attributes = s.r.Attributes(e.Attributes)
+		redacted[i] = e
+	}
+	return redacted
+}
+
+func (s *span) Status() sdktrace.Status {
+	status := s.ReadOnlySpan.Status()
+	status.Description = s.r.String(status.Description)
+	return status
+}
+
+// Attributes redacts attributes the way NewCore redacts zap fields.
+func (r *Redactor) Attributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	redacted := make([]attribute.KeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		key := string(kv.Key)
+		switch {
+		case r.Sensitive(key) && kv.Value.Type() == attribute.STRINGSLICE:
+			if r.mode == Drop {
+				continue
+			}
+			values := kv.Value.AsStringSlice()
+			for i, v := range values {
+				values[i], _ = r.Value(key, v)
+			}
+			kv = attribute.StringSlice(key, values)
+		case r.Sensitive(key):
+			v, ok := r.Value(key, kv.Value.Emit())
+			if !ok {
+				continue
+			}
+			kv = attribute.String(key, v)
+		case kv.Value.Type() == attribute.STRING:
+			kv = attribute.String(key, r.String(kv.Value.AsString()))
+		case kv.Value.Type() == attribute.STRINGSLICE:
+			values := kv.Value.AsStringSlice()
+			for i, v := range values {
+				values[i] = r.String(v)
+			}
+			kv = attribute.StringSlice(key, values)
+		}
+		redacted = append(redacted, kv)
+	}
+	return redacted
+}
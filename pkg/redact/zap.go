@@ -0,0 +1,88 @@
+package redact
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type core struct {
+	zapcore.Core
+	r *Redactor
+}
+
+// NewCore redacts the message and fields of every entry before passing it
+// to c: fields named like personal data as a whole, and strings, errors
+// and stringers by what they contain. Other fields pass unchanged.
+func NewCore(c zapcore.Core, r *Redactor) zapcore.Core {
+	return &core{Core: c, r: r}
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	return &core{Core: c.Core.With(c.r.fields(fields)), r: c.r}
+}
+
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.r.String(ent.Message)
+	return c.Core.Write(ent, c.r.fields(fields))
+}
+
+func (r *Redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		if f.Type == zapcore.SkipType {
+			redacted = append(redacted, f)
+			continue
+		}
+		if r.Sensitive(f.Key) {
+			if v, ok := r.Value(f.Key, fieldString(f)); ok {
+				redacted = append(redacted, zap.String(f.Key, v))
+			}
+			continue
+		}
+		switch f.Type {
+		case zapcore.StringType:
+			f.String = r.String(f.String)
+		case zapcore.ErrorType:
+			if err, ok := f.Interface.(error); ok {
+				f.Interface = &redactedError{msg: r.String(err.Error()), err: err}
+			}
+		case zapcore.StringerType, zapcore.ByteStringType:
+			f = zap.String(f.Key, r.String(fieldString(f)))
+		}
+		redacted = append(redacted, f)
+	}
+	return redacted
+}
+
+// fieldString returns the value of f the way an encoder would write it.
+func fieldString(f zapcore.Field) string {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	switch v := enc.Fields[f.Key].(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// redactedError keeps the original error for errors.Is and errors.As but
+// hides it from encoders, which would otherwise print the verbose form.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
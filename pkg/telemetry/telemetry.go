@@ -3,49 +3,66 @@ package telemetry
 import (
 	"context"
 	"fmt"
+	"net/http"
 
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+
+	"labgrab/user_service/pkg/redact"
 )
 
 type Config struct {
-	ServiceName    string
-	JaegerEndpoint string
-	Environment    string
+	ServiceName string
+	Environment string
+	// Exporter is "otlp", "console" (stdout) or "none" for the signal being
+	// initialized. With "none" spans are still created, so trace IDs reach
+	// logs and exemplars.
+	Exporter string
+	// Protocol is the OTLP protocol, "grpc" or "http/protobuf". The
+	// endpoint, headers and timeout come from the OTEL_EXPORTER_OTLP_*
+	// variables.
+	Protocol string
+	// Redactor, when set, removes personal data from exported spans.
+	Redactor *redact.Redactor
 }
 
-func InitTracer(cfg *Config) (*sdktrace.TracerProvider, error) {
-	exp, err := jaeger.New(
-		jaeger.WithCollectorEndpoint(
-			jaeger.WithEndpoint(cfg.JaegerEndpoint),
-		),
-	)
+// InitTracer sets up the global tracer provider. The SDK builds the sampler
+// from OTEL_TRACES_SAMPLER and OTEL_TRACES_SAMPLER_ARG, parentbased_always_on
+// by default.
+func InitTracer(ctx context.Context, cfg *Config) (*sdktrace.TracerProvider, error) {
+	res, err := newResource(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Jaeger exporter: %w", err)
+		return nil, err
 	}
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
 
-	res, err := resource.Merge(
-		resource.Default(),
-		resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceName(cfg.ServiceName),
-			semconv.DeploymentEnvironmentName(cfg.Environment),
-		),
-	)
-
+	exp, err := newExporter(ctx, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
+		return nil, err
+	}
+	if exp != nil {
+		if cfg.Redactor != nil {
+			exp = redact.NewSpanExporter(exp, cfg.Redactor)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
 	}
 
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exp),
-		sdktrace.WithResource(res),
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-	)
+	tp := sdktrace.NewTracerProvider(opts...)
 
 	otel.SetTracerProvider(tp)
 
@@ -59,6 +76,111 @@ func InitTracer(cfg *Config) (*sdktrace.TracerProvider, error) {
 	return tp, nil
 }
 
+func newExporter(ctx context.Context, cfg *Config) (sdktrace.SpanExporter, error) {
+	var (
+		exp sdktrace.SpanExporter
+		err error
+	)
+	switch cfg.Exporter {
+	case "none":
+		return nil, nil
+	case "console":
+		exp, err = stdouttrace.New()
+	case "otlp":
+		if cfg.Protocol == "http/protobuf" {
+			exp, err = otlptracehttp.New(ctx)
+		} else {
+			exp, err = otlptracegrpc.New(ctx)
+		}
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", cfg.Exporter, err)
+	}
+	return exp, nil
+}
+
+// InitLogger returns a logger provider for the zap bridge in pkg/logger,
+// or nil when logs are not exported. Records emitted with the context of a
+// span carry its trace and span IDs.
+func InitLogger(ctx context.Context, cfg *Config) (*sdklog.LoggerProvider, error) {
+	var (
+		exp sdklog.Exporter
+		err error
+	)
+	switch cfg.Exporter {
+	case "none":
+		return nil, nil
+	case "console":
+		exp, err = stdoutlog.New()
+	case "otlp":
+		if cfg.Protocol == "http/protobuf" {
+			exp, err = otlploghttp.New(ctx)
+		} else {
+			exp, err = otlploggrpc.New(ctx)
+		}
+	default:
+		return nil, fmt.Errorf("unknown logs exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s log exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := newResource(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exp)),
+	), nil
+}
+
+// InitMeter installs a meter provider exported to Prometheus and returns
+// the handler serving the metrics. The handler speaks OpenMetrics when the
+// scraper asks for it, which carries exemplars linking to trace IDs.
+func InitMeter(cfg *Config) (*sdkmetric.MeterProvider, http.Handler, error) {
+	registry := prom.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	exp, err := prometheus.New(prometheus.WithRegisterer(registry))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Prometheus exporter: %w", err)
+	}
+
+	res, err := newResource(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(exp),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(mp)
+
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+	return mp, handler, nil
+}
+
+func newResource(cfg *Config) (*resource.Resource, error) {
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.DeploymentEnvironmentNameKey.String(cfg.Environment),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+	return res, nil
+}
+
 func Shutdown(ctx context.Context, tp *sdktrace.TracerProvider) error {
 	if tp == nil {
 		return nil
@@ -0,0 +1,138 @@
+package telemetry_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"labgrab/user_service/api/proto"
+	"labgrab/user_service/internal/interceptor"
+	"labgrab/user_service/internal/repository"
+	"labgrab/user_service/internal/service"
+	"labgrab/user_service/internal/testserver"
+	"labgrab/user_service/pkg/telemetry"
+)
+
+func TestInitMeter(t *testing.T) {
+	mp, handler, err := telemetry.InitMeter(&telemetry.Config{ServiceName: "user-service-test", Environment: "DEV"})
+	if err != nil {
+		t.Fatalf("InitMeter: %v", err)
+	}
+	t.Cleanup(func() { mp.Shutdown(context.Background()) })
+	// Exemplars are only kept for measurements within a sampled span.
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample())))
+
+	s := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(interceptor.UnaryCommon(zap.NewNop())...),
+	)
+	proto.RegisterUserServiceServer(s, &service.Service{Repo: repository.NewMemory()})
+	conn, err := grpc.NewClient(testserver.Target,
+		testserver.Serve(t, s),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer conn.Close()
+	client := proto.NewUserServiceClient(conn)
+
+	ctx := context.Background()
+	const userUUID = "0b7f9c1e-4c1a-4d6e-9f6b-2a8d3c5e7f10"
+	if _, err := client.CreateUser(ctx, &proto.CreateUserRequest{Uuid: userUUID}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if _, err := client.CreateUser(ctx, &proto.CreateUserRequest{Uuid: userUUID}); err == nil {
+		t.Fatal("second CreateUser succeeded")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	body, _ := io.ReadAll(rec.Body)
+	metrics := string(body)
+
+	for _, want := range []*regexp.Regexp{
+		regexp.MustCompile(`rpc_server_call_duration_seconds_count\{[^}]*rpc_method="proto.UserService/CreateUser"[^}]*rpc_response_status_code="ALREADY_EXISTS"[^}]*\} 1`),
+		regexp.MustCompile(`rpc_server_call_duration_seconds_count\{[^}]*rpc_method="proto.UserService/CreateUser"[^}]*rpc_response_status_code="OK"[^}]*\} 1`),
+		regexp.MustCompile(`users_created_total\{[^}]*\} 1(\.0)? # \{[^}]*trace_id="[0-9a-f]{32}"`),
+	} {
+		if !want.MatchString(metrics) {
+			t.Errorf("metrics do not match %s", want)
+		}
+	}
+	if t.Failed() {
+		for _, line := range strings.Split(metrics, "\n") {
+			if strings.HasPrefix(line, "rpc_server") || strings.HasPrefix(line, "users_") {
+				t.Log(line)
+			}
+		}
+	}
+}
+
+func TestInitTracerSampler(t *testing.T) {
+	remoteParent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+
+	tests := []struct {
+		name        string
+		sampler     string
+		arg         string
+		wantRoot    bool
+		wantInherit bool
+	}{
+		{"default", "", "", true, true},
+		{"ratio 0 follows the parent", "parentbased_traceidratio", "0", false, true},
+		{"always off", "always_off", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_TRACES_SAMPLER", tt.sampler)
+			t.Setenv("OTEL_TRACES_SAMPLER_ARG", tt.arg)
+			tp, err := telemetry.InitTracer(context.Background(), &telemetry.Config{
+				ServiceName: "user-service-test",
+				Exporter:    "none",
+			})
+			if err != nil {
+				t.Fatalf("InitTracer: %v", err)
+			}
+			defer tp.Shutdown(context.Background())
+			tracer := tp.Tracer("test")
+
+			_, root := tracer.Start(context.Background(), "root")
+			root.End()
+			if got := root.SpanContext().IsSampled(); got != tt.wantRoot {
+				t.Errorf("root sampled = %v, want %v", got, tt.wantRoot)
+			}
+
+			_, child := tracer.Start(trace.ContextWithRemoteSpanContext(context.Background(), remoteParent), "child")
+			child.End()
+			if got := child.SpanContext().IsSampled(); got != tt.wantInherit {
+				t.Errorf("child of a sampled parent sampled = %v, want %v", got, tt.wantInherit)
+			}
+		})
+	}
+}
+
+func TestInitTracerUnknownExporter(t *testing.T) {
+	if _, err := telemetry.InitTracer(context.Background(), &telemetry.Config{Exporter: "jaeger"}); err == nil {
+		t.Error("InitTracer with an unknown exporter succeeded")
+	}
+}